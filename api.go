@@ -0,0 +1,281 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/auth0/go-auth0"
+	"github.com/auth0/go-auth0/management"
+
+	"github.com/olvesh/auth0-role-manager/v2/internal/api"
+)
+
+// ManagementAPI is the part of the Auth0 Management API used by the role
+// manager. It is implemented by NewManagementAPI on top of go-auth0, and by
+// the in-memory fake of the auth0test package for tests. Errors carrying an
+// HTTP status, like not found, implement management.Error.
+type ManagementAPI interface {
+	ListUsers(ctx context.Context, opts ListOptions) (*management.UserList, error)
+	// ExportUsers lists all the users with a users export job, unlike
+	// ListUsers not limited to 1000 users.
+	ExportUsers(ctx context.Context, fields []string) ([]*management.User, error)
+	ListUsersByEmail(ctx context.Context, email string, fields []string) ([]*management.User, error)
+	ReadUser(ctx context.Context, id string, fields []string) (*management.User, error)
+	UserRoles(ctx context.Context, userID string, opts ListOptions) (*management.RoleList, error)
+	UserPermissions(ctx context.Context, userID string, opts ListOptions) (*management.PermissionList, error)
+	UserOrganizations(ctx context.Context, userID string, opts ListOptions) (*management.OrganizationList, error)
+	AssignUserRoles(ctx context.Context, userID string, roleIDs []string) error
+	RemoveUserRoles(ctx context.Context, userID string, roleIDs []string) error
+
+	ListRoles(ctx context.Context, opts ListOptions) (*management.RoleList, error)
+	ReadRole(ctx context.Context, id string) (*management.Role, error)
+	CreateRole(ctx context.Context, role *management.Role) error
+	// RoleUsers supports checkpoint pagination, see ListOptions.From.
+	RoleUsers(ctx context.Context, roleID string, opts ListOptions) (*management.UserList, error)
+	RolePermissions(ctx context.Context, roleID string, opts ListOptions) (*management.PermissionList, error)
+
+	ListOrganizations(ctx context.Context, opts ListOptions) (*management.OrganizationList, error)
+	ReadOrganizationByName(ctx context.Context, name string) (*management.Organization, error)
+	OrganizationMembers(ctx context.Context, orgID string, opts ListOptions) (*management.OrganizationMemberList, error)
+	OrganizationMemberRoles(ctx context.Context, orgID string, userID string, opts ListOptions) (*management.OrganizationMemberRoleList, error)
+	AssignOrganizationMemberRoles(ctx context.Context, orgID string, userID string, roleIDs []string) error
+	RemoveOrganizationMemberRoles(ctx context.Context, orgID string, userID string, roleIDs []string) error
+}
+
+// ListOptions are the options of the list calls of a ManagementAPI.
+type ListOptions = api.ListOptions
+
+// requestOptions returns the go-auth0 request options of ctx and opts.
+func requestOptions(ctx context.Context, opts ListOptions) []management.RequestOption {
+	res := []management.RequestOption{management.Context(ctx)}
+	if opts.Take > 0 {
+		if opts.From != "" {
+			res = append(res, management.From(opts.From))
+		}
+		res = append(res, management.Take(opts.Take))
+	} else if opts.PerPage > 0 {
+		res = append(res, management.Page(opts.Page), management.PerPage(opts.PerPage))
+	}
+	if opts.Fields != nil {
+		res = append(res, management.IncludeFields(opts.Fields...))
+	}
+	if opts.Query != "" {
+		res = append(res, management.Query(opts.Query))
+	}
+	if opts.NameFilter != "" {
+		res = append(res, management.Parameter("name_filter", opts.NameFilter))
+	}
+	return res
+}
+
+// NewManagementAPI returns the ManagementAPI calling Auth0 through m. The
+// users exports are downloaded with http.DefaultClient.
+func NewManagementAPI(m *management.Management) ManagementAPI {
+	return newManagementAPI(m, http.DefaultClient)
+}
+
+// newManagementAPI returns the ManagementAPI calling Auth0 through m, and
+// downloading the users exports with download.
+func newManagementAPI(m *management.Management, download *http.Client) ManagementAPI {
+	return &managementAPI{m: m, download: download}
+}
+
+type managementAPI struct {
+	m *management.Management
+	// download fetches the files of the users exports, which are not
+	// Management API calls.
+	download *http.Client
+}
+
+func fieldOptions(ctx context.Context, fields []string) []management.RequestOption {
+	opts := []management.RequestOption{management.Context(ctx)}
+	if fields != nil {
+		opts = append(opts, management.IncludeFields(fields...))
+	}
+	return opts
+}
+
+func rolesOf(roleIDs []string) []*management.Role {
+	roles := make([]*management.Role, 0, len(roleIDs))
+	for i := range roleIDs {
+		roles = append(roles, &management.Role{ID: &roleIDs[i]})
+	}
+	return roles
+}
+
+func (a *managementAPI) ListUsers(ctx context.Context, opts ListOptions) (*management.UserList, error) {
+	return a.m.User.List(requestOptions(ctx, opts)...)
+}
+
+// exportFields are the fields of the users exported when all are requested.
+// Export jobs only have the fields they are given, and identities cannot be
+// exported as a whole.
+var exportFields = []string{
+	"user_id", "email", "email_verified", "username", "phone_number", "name", "nickname",
+	"blocked", "created_at", "last_login", "last_ip", "logins_count", "app_metadata", "user_metadata",
+}
+
+// exportPollInterval is the interval between the checks of the status of a
+// users export job.
+var exportPollInterval = 2 * time.Second
+
+func (a *managementAPI) ExportUsers(ctx context.Context, fields []string) ([]*management.User, error) {
+	if fields == nil {
+		fields = exportFields
+	}
+	job := &management.Job{Format: auth0.String("json")}
+	for _, field := range fields {
+		job.Fields = append(job.Fields, map[string]interface{}{"name": field})
+	}
+	if err := a.m.Job.ExportUsers(job, management.Context(ctx)); err != nil {
+		return nil, err
+	}
+	for job.GetStatus() != "completed" {
+		if job.GetStatus() == "failed" {
+			return nil, fmt.Errorf("error: users export job %s failed", job.GetID())
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(exportPollInterval):
+		}
+		var err error
+		if job, err = a.m.Job.Read(job.GetID(), management.Context(ctx)); err != nil {
+			return nil, err
+		}
+	}
+	return downloadUsers(ctx, a.download, job.GetLocation())
+}
+
+// downloadUsers reads the users of a users export job from location, a
+// gzipped file of one JSON user per line. location is a signed URL, which
+// must be fetched without the Management API credentials.
+func downloadUsers(ctx context.Context, client *http.Client, location string) ([]*management.User, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error: downloading the users export failed: %s", resp.Status)
+	}
+
+	r, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	users := []*management.User{}
+	decoder := json.NewDecoder(r)
+	for {
+		var user management.User
+		err := decoder.Decode(&user)
+		if err == io.EOF {
+			return users, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+}
+
+func (a *managementAPI) ListUsersByEmail(ctx context.Context, email string, fields []string) ([]*management.User, error) {
+	return a.m.User.ListByEmail(email, fieldOptions(ctx, fields)...)
+}
+
+func (a *managementAPI) ReadUser(ctx context.Context, id string, fields []string) (*management.User, error) {
+	return a.m.User.Read(id, fieldOptions(ctx, fields)...)
+}
+
+func (a *managementAPI) UserRoles(ctx context.Context, userID string, opts ListOptions) (*management.RoleList, error) {
+	return a.m.User.Roles(userID, requestOptions(ctx, opts)...)
+}
+
+func (a *managementAPI) UserPermissions(ctx context.Context, userID string, opts ListOptions) (*management.PermissionList, error) {
+	return a.m.User.Permissions(userID, requestOptions(ctx, opts)...)
+}
+
+func (a *managementAPI) UserOrganizations(ctx context.Context, userID string, opts ListOptions) (*management.OrganizationList, error) {
+	return a.m.User.Organizations(userID, requestOptions(ctx, opts)...)
+}
+
+func (a *managementAPI) AssignUserRoles(ctx context.Context, userID string, roleIDs []string) error {
+	return a.m.User.AssignRoles(userID, rolesOf(roleIDs), management.Context(ctx))
+}
+
+func (a *managementAPI) RemoveUserRoles(ctx context.Context, userID string, roleIDs []string) error {
+	return a.m.User.RemoveRoles(userID, rolesOf(roleIDs), management.Context(ctx))
+}
+
+func (a *managementAPI) ListRoles(ctx context.Context, opts ListOptions) (*management.RoleList, error) {
+	return a.m.Role.List(requestOptions(ctx, opts)...)
+}
+
+func (a *managementAPI) ReadRole(ctx context.Context, id string) (*management.Role, error) {
+	return a.m.Role.Read(id, management.Context(ctx))
+}
+
+func (a *managementAPI) CreateRole(ctx context.Context, role *management.Role) error {
+	return a.m.Role.Create(role, management.Context(ctx))
+}
+
+func (a *managementAPI) RoleUsers(ctx context.Context, roleID string, opts ListOptions) (*management.UserList, error) {
+	if opts.Take == 0 {
+		return a.m.Role.Users(roleID, requestOptions(ctx, opts)...)
+	}
+	// Role.Users adds the page parameters, which checkpoints do not take.
+	var list *management.UserList
+	err := a.m.Request(http.MethodGet, a.m.URI("roles", roleID, "users"), &list, requestOptions(ctx, opts)...)
+	return list, err
+}
+
+func (a *managementAPI) RolePermissions(ctx context.Context, roleID string, opts ListOptions) (*management.PermissionList, error) {
+	return a.m.Role.Permissions(roleID, requestOptions(ctx, opts)...)
+}
+
+func (a *managementAPI) ListOrganizations(ctx context.Context, opts ListOptions) (*management.OrganizationList, error) {
+	return a.m.Organization.List(requestOptions(ctx, opts)...)
+}
+
+func (a *managementAPI) ReadOrganizationByName(ctx context.Context, name string) (*management.Organization, error) {
+	return a.m.Organization.ReadByName(name, management.Context(ctx))
+}
+
+func (a *managementAPI) OrganizationMembers(ctx context.Context, orgID string, opts ListOptions) (*management.OrganizationMemberList, error) {
+	return a.m.Organization.Members(orgID, requestOptions(ctx, opts)...)
+}
+
+func (a *managementAPI) OrganizationMemberRoles(ctx context.Context, orgID string, userID string, opts ListOptions) (*management.OrganizationMemberRoleList, error) {
+	return a.m.Organization.MemberRoles(orgID, userID, requestOptions(ctx, opts)...)
+}
+
+func (a *managementAPI) AssignOrganizationMemberRoles(ctx context.Context, orgID string, userID string, roleIDs []string) error {
+	return a.m.Organization.AssignMemberRoles(orgID, userID, roleIDs, management.Context(ctx))
+}
+
+func (a *managementAPI) RemoveOrganizationMemberRoles(ctx context.Context, orgID string, userID string, roleIDs []string) error {
+	return a.m.Organization.DeleteMemberRoles(orgID, userID, roleIDs, management.Context(ctx))
+}
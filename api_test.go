@@ -0,0 +1,165 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestManagementAPI(t *testing.T) {
+	var query url.Values
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/users", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		fmt.Fprint(w, `{"users": [{"user_id": "auth0|alice"}], "start": 200, "limit": 100, "total": 201}`)
+	})
+	mux.HandleFunc("/api/v2/roles", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		fmt.Fprint(w, `{"roles": [], "start": 0, "limit": 50, "total": 0}`)
+	})
+	mux.HandleFunc("/api/v2/roles/rol_admin/users", func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		fmt.Fprint(w, `{"users": [{"user_id": "auth0|alice"}], "next": "b2Zmc2V0OjE="}`)
+	})
+	rm := newTestRoleManager(t, mux)
+	ctx := context.Background()
+
+	users, err := rm.api.ListUsers(ctx, ListOptions{Page: 2, PerPage: 100, Fields: []string{"user_id", "email"}, Query: `email:"alice@example.com"`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users.Users) != 1 || users.HasNext() {
+		t.Errorf("users: %v, supposed to be the last page with auth0|alice", users)
+	}
+	for key, value := range map[string]string{
+		"page": "2", "per_page": "100", "fields": "user_id,email", "include_fields": "true",
+		"q": `email:"alice@example.com"`, "search_engine": "v3",
+	} {
+		if query.Get(key) != value {
+			t.Errorf("%s: %q, supposed to be %q", key, query.Get(key), value)
+		}
+	}
+
+	if _, err := rm.api.ListRoles(ctx, ListOptions{NameFilter: "admin"}); err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("name_filter") != "admin" || query.Has("page") {
+		t.Errorf("query: %v, supposed to filter by name without paging", query)
+	}
+
+	users, err = rm.api.RoleUsers(ctx, "rol_admin", ListOptions{From: "YWJj", Take: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users.Users) != 1 || users.Next != "b2Zmc2V0OjE=" {
+		t.Errorf("users: %v, supposed to be auth0|alice with the next checkpoint", users)
+	}
+	if query.Get("from") != "YWJj" || query.Get("take") != "50" || query.Has("page") || query.Has("include_totals") {
+		t.Errorf("query: %v, supposed to use the checkpoint without paging", query)
+	}
+}
+
+func TestExportUsers(t *testing.T) {
+	defer func(interval time.Duration) { exportPollInterval = interval }(exportPollInterval)
+	exportPollInterval = time.Millisecond
+
+	var job map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/jobs/users-exports", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&job)
+		fmt.Fprint(w, `{"id": "job_1", "status": "pending"}`)
+	})
+	mux.HandleFunc("/api/v2/jobs/job_1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id": "job_1", "status": "completed", "location": "http://%s/export"}`, r.Host)
+	})
+	mux.HandleFunc("/export", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Error("the export should be downloaded without the credentials")
+		}
+		gz := gzip.NewWriter(w)
+		fmt.Fprint(gz, `{"user_id": "auth0|alice", "email": "alice@example.com"}
+{"user_id": "auth0|bob", "app_metadata": {"beta": true}}
+`)
+		_ = gz.Close()
+	})
+	rm := newTestRoleManager(t, mux)
+
+	users, err := rm.api.ExportUsers(context.Background(), []string{"user_id", "email"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].GetEmail() != "alice@example.com" || users[1].AppMetadata == nil || (*users[1].AppMetadata)["beta"] != true {
+		t.Errorf("users: %v, supposed to be alice and bob", users)
+	}
+	if fields := fmt.Sprint(job["fields"]); job["format"] != "json" || fields != "[map[name:user_id] map[name:email]]" {
+		t.Errorf("job: %v, supposed to export the user_id and email fields as JSON", job)
+	}
+}
+
+// pathRecorder is an http.RoundTripper recording the paths of the requests.
+type pathRecorder struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (r *pathRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.paths = append(r.paths, req.URL.Path)
+	r.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestExportDownloadClient(t *testing.T) {
+	defer func(interval time.Duration) { exportPollInterval = interval }(exportPollInterval)
+	exportPollInterval = time.Millisecond
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/jobs/users-exports", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id": "job_1", "status": "completed", "location": "http://%s/export"}`, r.Host)
+	})
+	mux.HandleFunc("/export", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	transport := &pathRecorder{}
+	rm := newTestRoleManager(t, mux,
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithRequestTimeout(50*time.Millisecond))
+
+	// A stalled download is bounded by the request timeout, and goes
+	// through the configured transport.
+	start := time.Now()
+	if _, err := rm.api.ExportUsers(context.Background(), nil); err == nil {
+		t.Error("the stalled download should fail")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("download: %s, supposed to time out after 50ms", d)
+	}
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.paths) != 2 || transport.paths[1] != "/export" {
+		t.Errorf("requests: %v, supposed to be the job and the download", transport.paths)
+	}
+}
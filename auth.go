@@ -0,0 +1,47 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// tokenSourcePlaceholder is the static token given to go-auth0 when the
+// tokens come from a token source, replaced by tokenTransport.
+const tokenSourcePlaceholder = "token-source"
+
+// tokenTransport authenticates the requests with the tokens of a token
+// source. go-auth0 has no option for a token source, so it replaces the
+// Authorization header set by go-auth0 from a placeholder static token.
+type tokenTransport struct {
+	base   http.RoundTripper
+	source oauth2.TokenSource
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.source.Token()
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	token.SetAuthHeader(req)
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,817 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auth0test provides an in-memory implementation of the Auth0
+// Management API calls of the role manager, to test code using it without
+// an Auth0 tenant:
+//
+//	fake := auth0test.New()
+//	alice := fake.AddUser(&management.User{Email: auth0.String("alice@example.com")})
+//	admin := fake.AddRole(&management.Role{Name: auth0.String("admin")})
+//	fake.AssignUserRoles(context.Background(), alice, []string{admin})
+//
+//	rm, err := auth0rolemanager.NewRoleManagerWithOptions("", "", "",
+//		auth0rolemanager.WithManagementAPI(fake))
+package auth0test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/auth0/go-auth0/management"
+
+	"github.com/olvesh/auth0-role-manager/v2/internal/api"
+)
+
+// defaultPerPage is the page size of the list calls not giving one, as in
+// Auth0.
+const defaultPerPage = 50
+
+// Fake is an in-memory auth0rolemanager.ManagementAPI. It is safe for
+// concurrent use. Users are searched with a subset of the Auth0 query
+// syntax: terms like field:"value" or field:value*, combined with AND, OR
+// and parentheses. User fields are not restricted, all are returned. As in
+// Auth0, only the first 1000 items of a list can be paged through, and
+// checkpoint pagination lists them all.
+type Fake struct {
+	mu sync.Mutex
+
+	nextID int
+
+	userIDs []string
+	users   map[string]*management.User
+	roleIDs []string
+	roles   map[string]*management.Role
+	orgIDs  []string
+	orgs    map[string]*management.Organization
+
+	calls     []Call
+	intercept func(Call) error
+
+	userRoles       map[string]map[string]bool
+	userPermissions map[string][]*management.Permission
+	rolePermissions map[string][]*management.Permission
+	members         map[string]map[string]bool
+	memberRoles     map[string]map[string]map[string]bool
+}
+
+// New returns an empty Fake.
+func New() *Fake {
+	return &Fake{
+		users:           map[string]*management.User{},
+		roles:           map[string]*management.Role{},
+		orgs:            map[string]*management.Organization{},
+		userRoles:       map[string]map[string]bool{},
+		userPermissions: map[string][]*management.Permission{},
+		rolePermissions: map[string][]*management.Permission{},
+		members:         map[string]map[string]bool{},
+		memberRoles:     map[string]map[string]map[string]bool{},
+	}
+}
+
+// Call is a call made to a Fake.
+type Call struct {
+	// Method is the name of the method called, e.g. "ListUsers".
+	Method string
+	// ID is the user, role or organization argument, if any: an ID, email
+	// or name, or "orgID/userID" for organization members.
+	ID string
+	// Options are the list options, or the user fields requested.
+	Options api.ListOptions
+	// RoleIDs are the roles assigned or removed.
+	RoleIDs []string
+}
+
+// Intercept sets a function called before every call, e.g. to make calls
+// fail or to block them. The call fails with its error if not nil. It is
+// called concurrently by concurrent calls.
+func (f *Fake) Intercept(fn func(Call) error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.intercept = fn
+}
+
+// Calls returns the calls made so far.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Call{}, f.calls...)
+}
+
+// CallCount returns the number of calls made so far to method.
+func (f *Fake) CallCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := 0
+	for _, c := range f.calls {
+		if c.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+// enter records a call and returns the error it fails with, if any.
+func (f *Fake) enter(ctx context.Context, c Call) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.calls = append(f.calls, c)
+	intercept := f.intercept
+	f.mu.Unlock()
+
+	if intercept != nil {
+		return intercept(c)
+	}
+	return nil
+}
+
+// Error is the error of the calls failing like in Auth0, implementing
+// management.Error.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+// Status returns the HTTP status of the error.
+func (e *Error) Status() int {
+	return e.StatusCode
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func notFound(format string, v ...interface{}) error {
+	return &Error{StatusCode: http.StatusNotFound, Message: fmt.Sprintf(format, v...)}
+}
+
+// newID returns a new ID starting with prefix. f.mu must be held.
+func (f *Fake) newID(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s%d", prefix, f.nextID)
+}
+
+// AddUser adds a user, or replaces the user with the same ID, and returns
+// its ID. An ID like auth0|1 is given to users without one.
+func (f *Fake) AddUser(user *management.User) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	u := *user
+	if u.ID == nil {
+		id := f.newID("auth0|")
+		u.ID = &id
+	}
+	if _, ok := f.users[u.GetID()]; !ok {
+		f.userIDs = append(f.userIDs, u.GetID())
+	}
+	f.users[u.GetID()] = &u
+	return u.GetID()
+}
+
+// DeleteUser deletes a user and its role assignments and memberships.
+func (f *Fake) DeleteUser(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.users, id)
+	f.userIDs = without(f.userIDs, id)
+	delete(f.userRoles, id)
+	delete(f.userPermissions, id)
+	for orgID := range f.members {
+		delete(f.members[orgID], id)
+		delete(f.memberRoles[orgID], id)
+	}
+}
+
+// AddRole adds a role, or replaces the role with the same ID, and returns
+// its ID. An ID like rol_1 is given to roles without one.
+func (f *Fake) AddRole(role *management.Role) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.addRole(role)
+}
+
+// addRole adds a role. f.mu must be held.
+func (f *Fake) addRole(role *management.Role) string {
+	r := *role
+	if r.ID == nil {
+		id := f.newID("rol_")
+		r.ID = &id
+	}
+	if _, ok := f.roles[r.GetID()]; !ok {
+		f.roleIDs = append(f.roleIDs, r.GetID())
+	}
+	f.roles[r.GetID()] = &r
+	return r.GetID()
+}
+
+// DeleteRole deletes a role and its assignments.
+func (f *Fake) DeleteRole(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.roles, id)
+	f.roleIDs = without(f.roleIDs, id)
+	delete(f.rolePermissions, id)
+	for _, roles := range f.userRoles {
+		delete(roles, id)
+	}
+	for _, members := range f.memberRoles {
+		for _, roles := range members {
+			delete(roles, id)
+		}
+	}
+}
+
+// AddRolePermissions adds permissions to a role.
+func (f *Fake) AddRolePermissions(roleID string, permissions ...*management.Permission) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.rolePermissions[roleID] = append(f.rolePermissions[roleID], permissions...)
+}
+
+// AddUserPermissions adds permissions to a user directly.
+func (f *Fake) AddUserPermissions(userID string, permissions ...*management.Permission) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.userPermissions[userID] = append(f.userPermissions[userID], permissions...)
+}
+
+// AddOrganization adds an organization with the given members, and returns
+// its ID. An ID like org_1 is given to organizations without one.
+func (f *Fake) AddOrganization(org *management.Organization, memberIDs ...string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	o := *org
+	if o.ID == nil {
+		id := f.newID("org_")
+		o.ID = &id
+	}
+	if _, ok := f.orgs[o.GetID()]; !ok {
+		f.orgIDs = append(f.orgIDs, o.GetID())
+		f.members[o.GetID()] = map[string]bool{}
+		f.memberRoles[o.GetID()] = map[string]map[string]bool{}
+	}
+	f.orgs[o.GetID()] = &o
+	for _, id := range memberIDs {
+		f.members[o.GetID()][id] = true
+	}
+	return o.GetID()
+}
+
+func without(ids []string, id string) []string {
+	res := ids[:0]
+	for _, i := range ids {
+		if i != id {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// maxPaged is the number of items reachable with page pagination, as in
+// Auth0.
+const maxPaged = 1000
+
+// page returns the bounds of the page of opts in n items, and fills in the
+// list envelope. Checkpoints are the offsets of the pages.
+func page(n int, opts api.ListOptions) (management.List, int, int, error) {
+	if opts.Take > 0 {
+		start := 0
+		if opts.From != "" {
+			var err error
+			if start, err = strconv.Atoi(opts.From); err != nil || start < 0 || start > n {
+				return management.List{}, 0, 0, &Error{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("invalid checkpoint %q", opts.From)}
+			}
+		}
+		end := start + opts.Take
+		if end > n {
+			end = n
+		}
+		list := management.List{Length: end - start}
+		if end < n {
+			list.Next = strconv.Itoa(end)
+		}
+		return list, start, end, nil
+	}
+
+	perPage := opts.PerPage
+	if perPage <= 0 {
+		perPage = defaultPerPage
+	}
+	start := opts.Page * perPage
+	if start+perPage > maxPaged {
+		return management.List{}, 0, 0, &Error{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("only the first %d items can be paged through", maxPaged)}
+	}
+	if start > n {
+		start = n
+	}
+	end := start + perPage
+	if end > n {
+		end = n
+	}
+	return management.List{Start: start, Limit: perPage, Length: end - start, Total: n}, start, end, nil
+}
+
+// sortedKeys returns the keys of set in the order of ids.
+func sortedKeys(ids []string, set map[string]bool) []string {
+	res := []string{}
+	for _, id := range ids {
+		if set[id] {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+func copyUser(u *management.User) *management.User {
+	c := *u
+	return &c
+}
+
+func copyRole(r *management.Role) *management.Role {
+	c := *r
+	return &c
+}
+
+// ListUsers lists the users matching opts.Query.
+func (f *Fake) ListUsers(ctx context.Context, opts api.ListOptions) (*management.UserList, error) {
+	if err := f.enter(ctx, Call{Method: "ListUsers", Options: opts}); err != nil {
+		return nil, err
+	}
+	match, err := parseQuery(opts.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	users := []*management.User{}
+	for _, id := range f.userIDs {
+		if match(f.users[id]) {
+			users = append(users, copyUser(f.users[id]))
+		}
+	}
+	list, start, end, err := page(len(users), opts)
+	if err != nil {
+		return nil, err
+	}
+	return &management.UserList{List: list, Users: users[start:end]}, nil
+}
+
+// ExportUsers lists all the users, as a users export job. fields are
+// ignored.
+func (f *Fake) ExportUsers(ctx context.Context, fields []string) ([]*management.User, error) {
+	if err := f.enter(ctx, Call{Method: "ExportUsers", Options: api.ListOptions{Fields: fields}}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	users := []*management.User{}
+	for _, id := range f.userIDs {
+		users = append(users, copyUser(f.users[id]))
+	}
+	return users, nil
+}
+
+// ListUsersByEmail lists the users with an email, ignoring case.
+func (f *Fake) ListUsersByEmail(ctx context.Context, email string, fields []string) ([]*management.User, error) {
+	if err := f.enter(ctx, Call{Method: "ListUsersByEmail", ID: email, Options: api.ListOptions{Fields: fields}}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	users := []*management.User{}
+	for _, id := range f.userIDs {
+		if strings.EqualFold(f.users[id].GetEmail(), email) {
+			users = append(users, copyUser(f.users[id]))
+		}
+	}
+	return users, nil
+}
+
+// ReadUser reads a user.
+func (f *Fake) ReadUser(ctx context.Context, id string, fields []string) (*management.User, error) {
+	if err := f.enter(ctx, Call{Method: "ReadUser", ID: id, Options: api.ListOptions{Fields: fields}}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user, ok := f.users[id]
+	if !ok {
+		return nil, notFound("user %s not found", id)
+	}
+	return copyUser(user), nil
+}
+
+// UserRoles lists the roles of a user.
+func (f *Fake) UserRoles(ctx context.Context, userID string, opts api.ListOptions) (*management.RoleList, error) {
+	if err := f.enter(ctx, Call{Method: "UserRoles", ID: userID, Options: opts}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.users[userID]; !ok {
+		return nil, notFound("user %s not found", userID)
+	}
+	ids := sortedKeys(f.roleIDs, f.userRoles[userID])
+	list, start, end, err := page(len(ids), opts)
+	if err != nil {
+		return nil, err
+	}
+	roles := []*management.Role{}
+	for _, id := range ids[start:end] {
+		roles = append(roles, copyRole(f.roles[id]))
+	}
+	return &management.RoleList{List: list, Roles: roles}, nil
+}
+
+// UserPermissions lists the permissions given to a user directly.
+func (f *Fake) UserPermissions(ctx context.Context, userID string, opts api.ListOptions) (*management.PermissionList, error) {
+	if err := f.enter(ctx, Call{Method: "UserPermissions", ID: userID, Options: opts}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.users[userID]; !ok {
+		return nil, notFound("user %s not found", userID)
+	}
+	return permissionList(f.userPermissions[userID], opts)
+}
+
+func permissionList(permissions []*management.Permission, opts api.ListOptions) (*management.PermissionList, error) {
+	list, start, end, err := page(len(permissions), opts)
+	if err != nil {
+		return nil, err
+	}
+	return &management.PermissionList{
+		List:        list,
+		Permissions: append([]*management.Permission{}, permissions[start:end]...),
+	}, nil
+}
+
+// UserOrganizations lists the organizations a user is a member of.
+func (f *Fake) UserOrganizations(ctx context.Context, userID string, opts api.ListOptions) (*management.OrganizationList, error) {
+	if err := f.enter(ctx, Call{Method: "UserOrganizations", ID: userID, Options: opts}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := []string{}
+	for _, id := range f.orgIDs {
+		if f.members[id][userID] {
+			ids = append(ids, id)
+		}
+	}
+	return f.organizationList(ids, opts)
+}
+
+// organizationList returns the page of opts of the organizations ids.
+// f.mu must be held.
+func (f *Fake) organizationList(ids []string, opts api.ListOptions) (*management.OrganizationList, error) {
+	list, start, end, err := page(len(ids), opts)
+	if err != nil {
+		return nil, err
+	}
+	orgs := []*management.Organization{}
+	for _, id := range ids[start:end] {
+		o := *f.orgs[id]
+		orgs = append(orgs, &o)
+	}
+	return &management.OrganizationList{List: list, Organizations: orgs}, nil
+}
+
+// checkRoles returns an error if a role of roleIDs does not exist.
+// f.mu must be held.
+func (f *Fake) checkRoles(roleIDs []string) error {
+	for _, id := range roleIDs {
+		if _, ok := f.roles[id]; !ok {
+			return notFound("role %s not found", id)
+		}
+	}
+	return nil
+}
+
+// AssignUserRoles assigns roles to a user.
+func (f *Fake) AssignUserRoles(ctx context.Context, userID string, roleIDs []string) error {
+	if err := f.enter(ctx, Call{Method: "AssignUserRoles", ID: userID, RoleIDs: roleIDs}); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.users[userID]; !ok {
+		return notFound("user %s not found", userID)
+	}
+	if err := f.checkRoles(roleIDs); err != nil {
+		return err
+	}
+	if f.userRoles[userID] == nil {
+		f.userRoles[userID] = map[string]bool{}
+	}
+	for _, id := range roleIDs {
+		f.userRoles[userID][id] = true
+	}
+	return nil
+}
+
+// RemoveUserRoles removes roles from a user.
+func (f *Fake) RemoveUserRoles(ctx context.Context, userID string, roleIDs []string) error {
+	if err := f.enter(ctx, Call{Method: "RemoveUserRoles", ID: userID, RoleIDs: roleIDs}); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.users[userID]; !ok {
+		return notFound("user %s not found", userID)
+	}
+	for _, id := range roleIDs {
+		delete(f.userRoles[userID], id)
+	}
+	return nil
+}
+
+// ListRoles lists the roles whose name contains opts.NameFilter, ignoring
+// case.
+func (f *Fake) ListRoles(ctx context.Context, opts api.ListOptions) (*management.RoleList, error) {
+	if err := f.enter(ctx, Call{Method: "ListRoles", Options: opts}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	filter := strings.ToLower(opts.NameFilter)
+	roles := []*management.Role{}
+	for _, id := range f.roleIDs {
+		if strings.Contains(strings.ToLower(f.roles[id].GetName()), filter) {
+			roles = append(roles, copyRole(f.roles[id]))
+		}
+	}
+	list, start, end, err := page(len(roles), opts)
+	if err != nil {
+		return nil, err
+	}
+	return &management.RoleList{List: list, Roles: roles[start:end]}, nil
+}
+
+// ReadRole reads a role.
+func (f *Fake) ReadRole(ctx context.Context, id string) (*management.Role, error) {
+	if err := f.enter(ctx, Call{Method: "ReadRole", ID: id}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	role, ok := f.roles[id]
+	if !ok {
+		return nil, notFound("role %s not found", id)
+	}
+	return copyRole(role), nil
+}
+
+// CreateRole creates a role, setting its ID. Role names are unique.
+func (f *Fake) CreateRole(ctx context.Context, role *management.Role) error {
+	if err := f.enter(ctx, Call{Method: "CreateRole", ID: role.GetName()}); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, r := range f.roles {
+		if r.GetName() == role.GetName() {
+			return &Error{StatusCode: http.StatusConflict, Message: fmt.Sprintf("role %s already exists", role.GetName())}
+		}
+	}
+	role.ID = nil
+	id := f.addRole(role)
+	role.ID = &id
+	return nil
+}
+
+// RoleUsers lists the users having a role, outside of organizations.
+func (f *Fake) RoleUsers(ctx context.Context, roleID string, opts api.ListOptions) (*management.UserList, error) {
+	if err := f.enter(ctx, Call{Method: "RoleUsers", ID: roleID, Options: opts}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.roles[roleID]; !ok {
+		return nil, notFound("role %s not found", roleID)
+	}
+	users := []*management.User{}
+	for _, id := range f.userIDs {
+		if f.userRoles[id][roleID] {
+			users = append(users, copyUser(f.users[id]))
+		}
+	}
+	list, start, end, err := page(len(users), opts)
+	if err != nil {
+		return nil, err
+	}
+	return &management.UserList{List: list, Users: users[start:end]}, nil
+}
+
+// RolePermissions lists the permissions of a role.
+func (f *Fake) RolePermissions(ctx context.Context, roleID string, opts api.ListOptions) (*management.PermissionList, error) {
+	if err := f.enter(ctx, Call{Method: "RolePermissions", ID: roleID, Options: opts}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.roles[roleID]; !ok {
+		return nil, notFound("role %s not found", roleID)
+	}
+	return permissionList(f.rolePermissions[roleID], opts)
+}
+
+// ListOrganizations lists the organizations.
+func (f *Fake) ListOrganizations(ctx context.Context, opts api.ListOptions) (*management.OrganizationList, error) {
+	if err := f.enter(ctx, Call{Method: "ListOrganizations", Options: opts}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.organizationList(f.orgIDs, opts)
+}
+
+// ReadOrganizationByName reads an organization by its name.
+func (f *Fake) ReadOrganizationByName(ctx context.Context, name string) (*management.Organization, error) {
+	if err := f.enter(ctx, Call{Method: "ReadOrganizationByName", ID: name}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, id := range f.orgIDs {
+		if f.orgs[id].GetName() == name {
+			o := *f.orgs[id]
+			return &o, nil
+		}
+	}
+	return nil, notFound("organization %s not found", name)
+}
+
+// OrganizationMembers lists the members of an organization.
+func (f *Fake) OrganizationMembers(ctx context.Context, orgID string, opts api.ListOptions) (*management.OrganizationMemberList, error) {
+	if err := f.enter(ctx, Call{Method: "OrganizationMembers", ID: orgID, Options: opts}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.orgs[orgID]; !ok {
+		return nil, notFound("organization %s not found", orgID)
+	}
+	ids := sortedKeys(f.userIDs, f.members[orgID])
+	list, start, end, err := page(len(ids), opts)
+	if err != nil {
+		return nil, err
+	}
+	members := []management.OrganizationMember{}
+	for _, id := range ids[start:end] {
+		user := f.users[id]
+		members = append(members, management.OrganizationMember{
+			UserID: user.ID,
+			Name:   user.Name,
+			Email:  user.Email,
+		})
+	}
+	return &management.OrganizationMemberList{List: list, Members: members}, nil
+}
+
+// checkMember returns an error if the user is not a member of the
+// organization. f.mu must be held.
+func (f *Fake) checkMember(orgID string, userID string) error {
+	if _, ok := f.orgs[orgID]; !ok {
+		return notFound("organization %s not found", orgID)
+	}
+	if !f.members[orgID][userID] {
+		return notFound("user %s is not a member of organization %s", userID, orgID)
+	}
+	return nil
+}
+
+// OrganizationMemberRoles lists the roles of a member of an organization.
+func (f *Fake) OrganizationMemberRoles(ctx context.Context, orgID string, userID string, opts api.ListOptions) (*management.OrganizationMemberRoleList, error) {
+	if err := f.enter(ctx, Call{Method: "OrganizationMemberRoles", ID: orgID + "/" + userID, Options: opts}); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkMember(orgID, userID); err != nil {
+		return nil, err
+	}
+	ids := sortedKeys(f.roleIDs, f.memberRoles[orgID][userID])
+	list, start, end, err := page(len(ids), opts)
+	if err != nil {
+		return nil, err
+	}
+	roles := []management.OrganizationMemberRole{}
+	for _, id := range ids[start:end] {
+		role := f.roles[id]
+		roles = append(roles, management.OrganizationMemberRole{
+			ID:          role.ID,
+			Name:        role.Name,
+			Description: role.Description,
+		})
+	}
+	return &management.OrganizationMemberRoleList{List: list, Roles: roles}, nil
+}
+
+// AssignOrganizationMemberRoles assigns roles to a member of an
+// organization.
+func (f *Fake) AssignOrganizationMemberRoles(ctx context.Context, orgID string, userID string, roleIDs []string) error {
+	if err := f.enter(ctx, Call{Method: "AssignOrganizationMemberRoles", ID: orgID + "/" + userID, RoleIDs: roleIDs}); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkMember(orgID, userID); err != nil {
+		return err
+	}
+	if err := f.checkRoles(roleIDs); err != nil {
+		return err
+	}
+	if f.memberRoles[orgID][userID] == nil {
+		f.memberRoles[orgID][userID] = map[string]bool{}
+	}
+	for _, id := range roleIDs {
+		f.memberRoles[orgID][userID][id] = true
+	}
+	return nil
+}
+
+// RemoveOrganizationMemberRoles removes roles from a member of an
+// organization.
+func (f *Fake) RemoveOrganizationMemberRoles(ctx context.Context, orgID string, userID string, roleIDs []string) error {
+	if err := f.enter(ctx, Call{Method: "RemoveOrganizationMemberRoles", ID: orgID + "/" + userID, RoleIDs: roleIDs}); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkMember(orgID, userID); err != nil {
+		return err
+	}
+	for _, id := range roleIDs {
+		delete(f.memberRoles[orgID][userID], id)
+	}
+	return nil
+}
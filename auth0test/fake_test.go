@@ -0,0 +1,144 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/auth0/go-auth0"
+	"github.com/auth0/go-auth0/management"
+	"github.com/casbin/casbin/v2/util"
+
+	auth0rolemanager "github.com/olvesh/auth0-role-manager/v2"
+)
+
+func newTestFake(t *testing.T) (*Fake, *auth0rolemanager.RoleManager) {
+	t.Helper()
+	f := New()
+	ctx := context.Background()
+	alice := f.AddUser(&management.User{Email: auth0.String("alice@example.com"), Identities: []*management.UserIdentity{{Connection: auth0.String("corp")}}})
+	bob := f.AddUser(&management.User{Email: auth0.String("bob@example.com"), Identities: []*management.UserIdentity{{Connection: auth0.String("google-oauth2")}}})
+	admin := f.AddRole(&management.Role{Name: auth0.String("admin")})
+	f.AddRole(&management.Role{Name: auth0.String("editor")})
+	f.AddRolePermissions(admin, &management.Permission{ResourceServerIdentifier: auth0.String("https://api"), Name: auth0.String("write")})
+	if err := f.AssignUserRoles(ctx, alice, []string{admin}); err != nil {
+		t.Fatal(err)
+	}
+	f.AddOrganization(&management.Organization{Name: auth0.String("acme")}, alice, bob)
+
+	rm, err := auth0rolemanager.NewRoleManagerWithOptions("", "", "",
+		auth0rolemanager.WithManagementAPI(f),
+		auth0rolemanager.WithPageSize(1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f, rm.(*auth0rolemanager.RoleManager)
+}
+
+func TestFake(t *testing.T) {
+	_, rm := newTestFake(t)
+
+	if roles, _ := rm.GetRoles("alice@example.com"); !util.ArrayEquals(roles, []string{"admin"}) {
+		t.Errorf("alice@example.com: %s, supposed to be [admin]", roles)
+	}
+	if users, _ := rm.GetUsers("admin"); !util.ArrayEquals(users, []string{"alice@example.com"}) {
+		t.Errorf("admin: %s, supposed to be [alice@example.com]", users)
+	}
+	if err := rm.AddLink("bob@example.com", "editor"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := rm.HasLink("bob@example.com", "editor"); !ok {
+		t.Error("bob@example.com < editor: false, supposed to be true")
+	}
+	if err := rm.AddLink("bob@example.com", "viewer"); err != nil {
+		t.Fatal(err)
+	}
+	if roles, _ := rm.GetRoles("bob@example.com"); !util.ArrayEquals(roles, []string{"editor", "viewer"}) {
+		t.Errorf("bob@example.com: %s, supposed to be [editor viewer]", roles)
+	}
+	if err := rm.DeleteLink("bob@example.com", "editor"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := rm.HasLink("bob@example.com", "editor"); ok {
+		t.Error("bob@example.com < editor: true, supposed to be false")
+	}
+
+	permissions, err := rm.GetPermissionsForUser("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(permissions) != 1 || permissions[0].String() != "https://api:write" {
+		t.Errorf("permissions of alice@example.com: %v, supposed to be [https://api:write]", permissions)
+	}
+
+	rm.EnableOrganizations(true)
+	if err := rm.AddLink("bob@example.com", "admin", "acme"); err != nil {
+		t.Fatal(err)
+	}
+	if users, _ := rm.GetUsers("admin", "acme"); !util.ArrayEquals(users, []string{"bob@example.com"}) {
+		t.Errorf("admin in acme: %s, supposed to be [bob@example.com]", users)
+	}
+	if domains, _ := rm.GetDomains("alice@example.com"); !util.ArrayEquals(domains, []string{"acme"}) {
+		t.Errorf("domains of alice@example.com: %s, supposed to be [acme]", domains)
+	}
+	if rm.ManagementClient() != nil {
+		t.Error("ManagementClient: not nil with a ManagementAPI")
+	}
+}
+
+func TestFakeQuery(t *testing.T) {
+	f, _ := newTestFake(t)
+	ctx := context.Background()
+
+	for query, want := range map[string][]string{
+		``:                             {"alice@example.com", "bob@example.com"},
+		`email:"alice@example.com"`:    {"alice@example.com"},
+		`email:bob*`:                   {"bob@example.com"},
+		`identities.connection:"corp"`: {"alice@example.com"},
+		`(identities.connection:"corp" OR identities.connection:"google-oauth2") AND (email:b*)`: {"bob@example.com"},
+	} {
+		list, err := f.ListUsers(ctx, auth0rolemanager.ListOptions{Query: query})
+		if err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		emails := []string{}
+		for _, u := range list.Users {
+			emails = append(emails, u.GetEmail())
+		}
+		if !util.ArrayEquals(emails, want) {
+			t.Errorf("%s: %s, supposed to be %s", query, emails, want)
+		}
+	}
+
+	_, err := f.ListUsers(ctx, auth0rolemanager.ListOptions{Query: `(email:"a"`})
+	if mErr, ok := err.(management.Error); !ok || mErr.Status() != http.StatusBadRequest {
+		t.Errorf("invalid query: %v, supposed to be a 400 error", err)
+	}
+	_, err = f.ReadRole(ctx, "rol_missing")
+	if mErr, ok := err.(management.Error); !ok || mErr.Status() != http.StatusNotFound {
+		t.Errorf("missing role: %v, supposed to be a 404 error", err)
+	}
+
+	list, err := f.ListUsers(ctx, auth0rolemanager.ListOptions{Page: 1, PerPage: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Users) != 1 || list.Users[0].GetEmail() != "bob@example.com" || list.HasNext() {
+		t.Errorf("page 1: %v, supposed to be the last page with bob@example.com", list)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/auth0/go-auth0/management"
+)
+
+// matcher reports whether a user matches a query.
+type matcher func(user *management.User) bool
+
+// parseQuery parses a user search query, see Fake. The empty query matches
+// every user.
+func parseQuery(query string) (matcher, error) {
+	if strings.TrimSpace(query) == "" {
+		return func(*management.User) bool { return true }, nil
+	}
+	p := &queryParser{query: query}
+	m, err := p.or()
+	if err == nil && p.next() != "" {
+		err = fmt.Errorf("unexpected %q", p.next())
+	}
+	if err != nil {
+		return nil, &Error{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf("invalid query %q: %v", query, err)}
+	}
+	return m, nil
+}
+
+type queryParser struct {
+	query string
+	pos   int
+}
+
+// next returns the next token without consuming it: a parenthesis, an
+// operator, a term or "" at the end.
+func (p *queryParser) next() string {
+	q := strings.TrimLeft(p.query[p.pos:], " ")
+	if q == "" {
+		return ""
+	}
+	if q[0] == '(' || q[0] == ')' {
+		return q[:1]
+	}
+	inQuote := false
+	for i := 0; i < len(q); i++ {
+		switch {
+		case q[i] == '\\' && inQuote:
+			i++
+		case q[i] == '"':
+			inQuote = !inQuote
+		case !inQuote && (q[i] == ' ' || q[i] == ')'):
+			return q[:i]
+		}
+	}
+	return q
+}
+
+// consume consumes the next token and returns it.
+func (p *queryParser) consume() string {
+	token := p.next()
+	p.pos = len(p.query) - len(strings.TrimLeft(p.query[p.pos:], " ")) + len(token)
+	return token
+}
+
+func (p *queryParser) or() (matcher, error) {
+	m, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.next() == "OR" {
+		p.consume()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left := m
+		m = func(u *management.User) bool { return left(u) || right(u) }
+	}
+	return m, nil
+}
+
+func (p *queryParser) and() (matcher, error) {
+	m, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.next() == "AND" {
+		p.consume()
+		right, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		left := m
+		m = func(u *management.User) bool { return left(u) && right(u) }
+	}
+	return m, nil
+}
+
+func (p *queryParser) operand() (matcher, error) {
+	token := p.consume()
+	switch token {
+	case "":
+		return nil, fmt.Errorf("unexpected end")
+	case "(":
+		m, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.consume() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		return m, nil
+	case ")", "AND", "OR":
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+
+	field, value, ok := strings.Cut(token, ":")
+	if !ok || field == "" || value == "" {
+		return nil, fmt.Errorf("term %q is not field:value", token)
+	}
+	prefix := false
+	if strings.HasPrefix(value, `"`) {
+		v, err := unquote(value)
+		if err != nil {
+			return nil, err
+		}
+		value = v
+	} else if strings.HasSuffix(value, "*") {
+		prefix = true
+		value = strings.TrimSuffix(value, "*")
+	}
+	path := strings.Split(field, ".")
+	return func(u *management.User) bool {
+		return matchValue(userFields(u), path, value, prefix)
+	}, nil
+}
+
+// unquote returns the value of a quoted term, in which a backslash escapes
+// the next character.
+func unquote(value string) (string, error) {
+	if len(value) < 2 || !strings.HasSuffix(value, `"`) {
+		return "", fmt.Errorf("unterminated %s", value)
+	}
+	var b strings.Builder
+	escaped := false
+	for _, r := range value[1 : len(value)-1] {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+			continue
+		case r == '"':
+			return "", fmt.Errorf("unescaped quote in %s", value)
+		}
+		b.WriteRune(r)
+	}
+	if escaped {
+		return "", fmt.Errorf("unterminated %s", value)
+	}
+	return b.String(), nil
+}
+
+// userFields returns the JSON fields of a user, as searched by Auth0.
+func userFields(u *management.User) interface{} {
+	b, err := json.Marshal(u)
+	if err != nil {
+		return nil
+	}
+	var fields interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// matchValue reports whether the value at path in v, or in any element of
+// the arrays on the way, is value, or starts with it if prefix is true.
+// Strings are compared ignoring case.
+func matchValue(v interface{}, path []string, value string, prefix bool) bool {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, e := range v {
+			if matchValue(e, path, value, prefix) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return false
+		}
+		return matchValue(v[path[0]], path[1:], value, prefix)
+	case nil:
+		return false
+	}
+	if len(path) != 0 {
+		return false
+	}
+	s := strings.ToLower(fmt.Sprint(v))
+	value = strings.ToLower(value)
+	if prefix {
+		return strings.HasPrefix(s, value)
+	}
+	return s == value
+}
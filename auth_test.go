@@ -0,0 +1,75 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// countingTokenSource counts the tokens it mints.
+type countingTokenSource struct {
+	tokens int
+}
+
+func (s *countingTokenSource) Token() (*oauth2.Token, error) {
+	s.tokens++
+	return &oauth2.Token{AccessToken: fmt.Sprintf("minted-%d", s.tokens), TokenType: "Bearer"}, nil
+}
+
+func TestTokenAuth(t *testing.T) {
+	var authorization string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"roles": [], "start": 0, "limit": 100, "total": 0}`)
+	}))
+	defer srv.Close()
+
+	source := &countingTokenSource{}
+	tests := []struct {
+		opt           Option
+		authorization string
+	}{
+		{WithStaticToken("static"), "Bearer static"},
+		{WithTokenSource(source), "Bearer minted-1"},
+	}
+	for _, test := range tests {
+		rm, err := newRoleManager("", "", srv.Listener.Addr().String(),
+			WithoutPreload(), WithHTTPClient(srv.Client()), test.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := rm.mgmtClient.Role.List(); err != nil {
+				t.Fatal(err)
+			}
+			if authorization != test.authorization {
+				t.Errorf("authorization: %q, supposed to be %q", authorization, test.authorization)
+			}
+		}
+	}
+	// The token is reused until it expires.
+	if source.tokens != 1 {
+		t.Errorf("tokens minted: %d, supposed to be 1", source.tokens)
+	}
+
+	if _, err := newRoleManager("", "", "abc", WithStaticToken("static"), WithTokenSource(source)); err == nil {
+		t.Error("a static token and a token source should be rejected together")
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2/log"
+	"github.com/casbin/casbin/v2/rbac"
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheOptions configures a CachedRoleManager.
+type CacheOptions struct {
+	// TTL is how long a result is kept. Zero keeps results until they are
+	// evicted or invalidated.
+	TTL time.Duration
+	// MaxEntries bounds the number of cached results, evicting the least
+	// recently used ones. Zero means no limit.
+	MaxEntries int
+	// OnHit and OnMiss, if not nil, are called with the method looked up,
+	// e.g. "HasLink", on every cache hit and miss.
+	OnHit  func(method string)
+	OnMiss func(method string)
+}
+
+// CacheStats are counters of the effectiveness of a CachedRoleManager.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Evictions is the number of results dropped to respect MaxEntries.
+	Evictions uint64
+	// Entries is the current number of cached results.
+	Entries int
+}
+
+// CachedRoleManager is a role manager caching the results of another one,
+// typically a role manager calling a remote service like Auth0.
+//
+// Results of HasLink, GetRoles and GetUsers are kept for CacheOptions.TTL,
+// and concurrent lookups of the same key share a single call to the inner
+// role manager. Errors are never cached. Adding or deleting a link, or
+// clearing the role manager, invalidates the whole cache.
+type CachedRoleManager struct {
+	inner rbac.RoleManager
+	opts  CacheOptions
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	// gen is incremented on invalidation, so that results loaded before
+	// are neither shared with nor stored for later lookups.
+	gen uint64
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+
+	group singleflight.Group
+	now   func() time.Time
+}
+
+type cacheEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// NewCachedRoleManager is the constructor of a CachedRoleManager.
+// inner is the role manager whose results are cached.
+func NewCachedRoleManager(inner rbac.RoleManager, opts CacheOptions) rbac.RoleManager {
+	return &CachedRoleManager{
+		inner:   inner,
+		opts:    opts,
+		entries: map[string]*list.Element{},
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+// Invalidate drops all the cached results.
+func (rm *CachedRoleManager) Invalidate() {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.entries = map[string]*list.Element{}
+	rm.lru.Init()
+	rm.gen++
+}
+
+// Stats returns the counters of the effectiveness of the cache.
+func (rm *CachedRoleManager) Stats() CacheStats {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	return CacheStats{
+		Hits:      rm.hits,
+		Misses:    rm.misses,
+		Evictions: rm.evictions,
+		Entries:   rm.lru.Len(),
+	}
+}
+
+// Clear clears the inner role manager and the cache.
+func (rm *CachedRoleManager) Clear() error {
+	defer rm.Invalidate()
+	return rm.inner.Clear()
+}
+
+// BuildRelationship is a no-op kept for the casbin v2 interface.
+//
+// Deprecated: BuildRelationship is no longer required by casbin.
+func (rm *CachedRoleManager) BuildRelationship(name1 string, name2 string, domain ...string) error {
+	return nil
+}
+
+// AddLink adds the inheritance link to the inner role manager.
+func (rm *CachedRoleManager) AddLink(name1 string, name2 string, domain ...string) error {
+	defer rm.Invalidate()
+	return rm.inner.AddLink(name1, name2, domain...)
+}
+
+// DeleteLink deletes the inheritance link from the inner role manager.
+func (rm *CachedRoleManager) DeleteLink(name1 string, name2 string, domain ...string) error {
+	defer rm.Invalidate()
+	return rm.inner.DeleteLink(name1, name2, domain...)
+}
+
+// HasLink determines whether role: name1 inherits role: name2.
+func (rm *CachedRoleManager) HasLink(name1 string, name2 string, domain ...string) (bool, error) {
+	res, err := rm.lookup(cacheKey("HasLink", name1, name2, domain), func() (interface{}, error) {
+		return rm.inner.HasLink(name1, name2, domain...)
+	})
+	if err != nil {
+		return false, err
+	}
+	return res.(bool), nil
+}
+
+// GetRoles gets the roles that a subject inherits.
+func (rm *CachedRoleManager) GetRoles(name string, domain ...string) ([]string, error) {
+	return rm.lookupNames(cacheKey("GetRoles", name, "", domain), func() ([]string, error) {
+		return rm.inner.GetRoles(name, domain...)
+	})
+}
+
+// GetUsers gets the users that inherits a subject.
+func (rm *CachedRoleManager) GetUsers(name string, domain ...string) ([]string, error) {
+	return rm.lookupNames(cacheKey("GetUsers", name, "", domain), func() ([]string, error) {
+		return rm.inner.GetUsers(name, domain...)
+	})
+}
+
+// GetImplicitRoles gets the implicit roles that a subject inherits.
+func (rm *CachedRoleManager) GetImplicitRoles(name string, domain ...string) ([]string, error) {
+	return rm.lookupNames(cacheKey("GetImplicitRoles", name, "", domain), func() ([]string, error) {
+		return rm.inner.GetImplicitRoles(name, domain...)
+	})
+}
+
+// GetImplicitUsers gets the implicit users that inherits a subject.
+func (rm *CachedRoleManager) GetImplicitUsers(name string, domain ...string) ([]string, error) {
+	return rm.lookupNames(cacheKey("GetImplicitUsers", name, "", domain), func() ([]string, error) {
+		return rm.inner.GetImplicitUsers(name, domain...)
+	})
+}
+
+// GetDomains gets the domains that a user has.
+func (rm *CachedRoleManager) GetDomains(name string) ([]string, error) {
+	return rm.lookupNames(cacheKey("GetDomains", name, "", nil), func() ([]string, error) {
+		return rm.inner.GetDomains(name)
+	})
+}
+
+// GetAllDomains gets all the domains.
+func (rm *CachedRoleManager) GetAllDomains() ([]string, error) {
+	return rm.lookupNames(cacheKey("GetAllDomains", "", "", nil), func() ([]string, error) {
+		return rm.inner.GetAllDomains()
+	})
+}
+
+// DeleteDomain deletes the data of a domain from the inner role manager.
+func (rm *CachedRoleManager) DeleteDomain(domain string) error {
+	defer rm.Invalidate()
+	return rm.inner.DeleteDomain(domain)
+}
+
+// PrintRoles prints all the roles of the inner role manager to log.
+func (rm *CachedRoleManager) PrintRoles() error {
+	return rm.inner.PrintRoles()
+}
+
+// SetLogger sets the logger of the inner role manager.
+func (rm *CachedRoleManager) SetLogger(logger log.Logger) {
+	rm.inner.SetLogger(logger)
+}
+
+// Match matches a name with a pattern in the inner role manager.
+func (rm *CachedRoleManager) Match(str string, pattern string) bool {
+	return rm.inner.Match(str, pattern)
+}
+
+// AddMatchingFunc adds the matching function to the inner role manager, and
+// invalidates the cache.
+func (rm *CachedRoleManager) AddMatchingFunc(name string, fn rbac.MatchingFunc) {
+	defer rm.Invalidate()
+	rm.inner.AddMatchingFunc(name, fn)
+}
+
+// AddDomainMatchingFunc adds the domain matching function to the inner role
+// manager, and invalidates the cache.
+func (rm *CachedRoleManager) AddDomainMatchingFunc(name string, fn rbac.MatchingFunc) {
+	defer rm.Invalidate()
+	rm.inner.AddDomainMatchingFunc(name, fn)
+}
+
+// lookupNames is lookup for results that are lists of names, returning a
+// copy of the cached list.
+func (rm *CachedRoleManager) lookupNames(key string, load func() ([]string, error)) ([]string, error) {
+	res, err := rm.lookup(key, func() (interface{}, error) {
+		return load()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{}, res.([]string)...), nil
+}
+
+// lookup returns the cached value of key, calling load once for all the
+// concurrent callers if it is missing or expired.
+func (rm *CachedRoleManager) lookup(key string, load func() (interface{}, error)) (interface{}, error) {
+	value, gen, ok := rm.get(key)
+	method := strings.SplitN(key, "\x00", 2)[0]
+	if ok {
+		if rm.opts.OnHit != nil {
+			rm.opts.OnHit(method)
+		}
+		return value, nil
+	}
+	if rm.opts.OnMiss != nil {
+		rm.opts.OnMiss(method)
+	}
+
+	value, err, _ := rm.group.Do(fmt.Sprintf("%d\x00%s", gen, key), func() (interface{}, error) {
+		value, err := load()
+		if err == nil {
+			rm.set(key, value, gen)
+		}
+		return value, err
+	})
+	return value, err
+}
+
+func (rm *CachedRoleManager) get(key string) (interface{}, uint64, bool) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	elem, ok := rm.entries[key]
+	if !ok {
+		rm.misses++
+		return nil, rm.gen, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && rm.now().After(entry.expires) {
+		rm.lru.Remove(elem)
+		delete(rm.entries, key)
+		rm.misses++
+		return nil, rm.gen, false
+	}
+	rm.lru.MoveToFront(elem)
+	rm.hits++
+	return entry.value, rm.gen, true
+}
+
+func (rm *CachedRoleManager) set(key string, value interface{}, gen uint64) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if gen != rm.gen {
+		return
+	}
+
+	entry := &cacheEntry{key: key, value: value}
+	if rm.opts.TTL > 0 {
+		entry.expires = rm.now().Add(rm.opts.TTL)
+	}
+
+	if elem, ok := rm.entries[key]; ok {
+		elem.Value = entry
+		rm.lru.MoveToFront(elem)
+		return
+	}
+	rm.entries[key] = rm.lru.PushFront(entry)
+
+	if rm.opts.MaxEntries > 0 && rm.lru.Len() > rm.opts.MaxEntries {
+		oldest := rm.lru.Back()
+		rm.lru.Remove(oldest)
+		delete(rm.entries, oldest.Value.(*cacheEntry).key)
+		rm.evictions++
+	}
+}
+
+func cacheKey(method string, name1 string, name2 string, domain []string) string {
+	return strings.Join(append([]string{method, name1, name2}, domain...), "\x00")
+}
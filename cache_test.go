@@ -0,0 +1,84 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2/rbac"
+	defaultrolemanager "github.com/casbin/casbin/v2/rbac/default-role-manager"
+	"github.com/casbin/casbin/v2/util"
+)
+
+// countingRoleManager counts the lookups reaching a role manager.
+type countingRoleManager struct {
+	rbac.RoleManager
+	calls int
+}
+
+func (rm *countingRoleManager) GetRoles(name string, domain ...string) ([]string, error) {
+	rm.calls++
+	return rm.RoleManager.GetRoles(name, domain...)
+}
+
+func TestCachedRoleManager(t *testing.T) {
+	inner := &countingRoleManager{RoleManager: defaultrolemanager.NewRoleManager(10)}
+	_ = inner.AddLink("alice@test.com", "Group1")
+
+	hits := 0
+	rm := NewCachedRoleManager(inner, CacheOptions{TTL: time.Minute, MaxEntries: 1, OnHit: func(method string) {
+		if method == "GetRoles" {
+			hits++
+		}
+	}})
+	now := time.Now()
+	rm.(*CachedRoleManager).now = func() time.Time { return now }
+
+	testPrintRoles(t, rm, "alice@test.com", []string{"Group1"})
+	testPrintRoles(t, rm, "alice@test.com", []string{"Group1"})
+	if inner.calls != 1 {
+		t.Errorf("calls: %d, supposed to be 1", inner.calls)
+	}
+
+	// Expired results are loaded again.
+	now = now.Add(2 * time.Minute)
+	testPrintRoles(t, rm, "alice@test.com", []string{"Group1"})
+	if inner.calls != 2 {
+		t.Errorf("calls: %d, supposed to be 2", inner.calls)
+	}
+
+	// The least recently used result is evicted.
+	testPrintRoles(t, rm, "bob@test.com", []string{})
+	testPrintRoles(t, rm, "alice@test.com", []string{"Group1"})
+	if inner.calls != 4 {
+		t.Errorf("calls: %d, supposed to be 4", inner.calls)
+	}
+
+	// Links invalidate the cache.
+	_ = rm.AddLink("alice@test.com", "Admin")
+	// casbin v2 returns the roles in no particular order.
+	if roles, _ := rm.GetRoles("alice@test.com"); !util.SetEquals(roles, []string{"Group1", "Admin"}) {
+		t.Errorf("alice@test.com: %s, supposed to be %s", roles, []string{"Group1", "Admin"})
+	}
+	if inner.calls != 5 {
+		t.Errorf("calls: %d, supposed to be 5", inner.calls)
+	}
+
+	stats := rm.(*CachedRoleManager).Stats()
+	if stats != (CacheStats{Hits: 1, Misses: 5, Evictions: 2, Entries: 1}) || hits != 1 {
+		t.Errorf("stats: %+v and %d hits reported, supposed to be 1 hit, 5 misses, 2 evictions and 1 entry", stats, hits)
+	}
+}
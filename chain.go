@@ -0,0 +1,235 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"github.com/casbin/casbin/v2/log"
+	"github.com/casbin/casbin/v2/rbac"
+)
+
+// ChainRoleManager is a role manager consulting a primary role manager
+// first and falling back to another one on a miss or an error. It can be
+// used to migrate gradually from casbin policies to Auth0, or to keep
+// emergency overrides in a local role manager.
+//
+// Links are added to and deleted from the fallback role manager only, as
+// casbin adds the grouping rules of its policy when loading it, which must
+// not be assigned in Auth0. See WithPrimaryWrites.
+type ChainRoleManager struct {
+	primary       rbac.RoleManager
+	fallback      rbac.RoleManager
+	logger        log.Logger
+	primaryWrites bool
+}
+
+// ChainOption is an option of a ChainRoleManager.
+type ChainOption func(rm *ChainRoleManager)
+
+// WithPrimaryWrites adds and deletes links in the primary role manager,
+// and in the fallback one only if the primary fails. With an Auth0 primary,
+// every grouping rule of the casbin policy is then assigned in Auth0 when the
+// policy is loaded.
+func WithPrimaryWrites() ChainOption {
+	return func(rm *ChainRoleManager) {
+		rm.primaryWrites = true
+	}
+}
+
+// NewChainRoleManager is the constructor of a ChainRoleManager.
+// primary is consulted first, usually the Auth0 role manager.
+// fallback is consulted when primary misses or fails, e.g. casbin's default role manager.
+func NewChainRoleManager(primary rbac.RoleManager, fallback rbac.RoleManager, opts ...ChainOption) rbac.RoleManager {
+	rm := &ChainRoleManager{primary: primary, fallback: fallback, logger: &log.DefaultLogger{}}
+	for _, opt := range opts {
+		opt(rm)
+	}
+	return rm
+}
+
+// Clear clears both role managers.
+func (rm *ChainRoleManager) Clear() error {
+	err := rm.primary.Clear()
+	if fallbackErr := rm.fallback.Clear(); err == nil {
+		err = fallbackErr
+	}
+	return err
+}
+
+// BuildRelationship is a no-op kept for the casbin v2 interface.
+//
+// Deprecated: BuildRelationship is no longer required by casbin.
+func (rm *ChainRoleManager) BuildRelationship(name1 string, name2 string, domain ...string) error {
+	return nil
+}
+
+// AddLink adds the inheritance link to the fallback role manager. With
+// WithPrimaryWrites, it is added to the primary role manager, or to the
+// fallback one if the primary fails.
+func (rm *ChainRoleManager) AddLink(name1 string, name2 string, domain ...string) error {
+	if !rm.primaryWrites {
+		return rm.fallback.AddLink(name1, name2, domain...)
+	}
+	err := rm.primary.AddLink(name1, name2, domain...)
+	if err == nil {
+		return nil
+	}
+	logPrintf(rm.logger, "Primary role manager failed to add link %s -> %s, using fallback: '%v'", name1, name2, err)
+	return rm.fallback.AddLink(name1, name2, domain...)
+}
+
+// DeleteLink deletes the inheritance link from the fallback role manager.
+// With WithPrimaryWrites, it is deleted from the primary role manager, or
+// from the fallback one if the primary fails.
+func (rm *ChainRoleManager) DeleteLink(name1 string, name2 string, domain ...string) error {
+	if !rm.primaryWrites {
+		return rm.fallback.DeleteLink(name1, name2, domain...)
+	}
+	err := rm.primary.DeleteLink(name1, name2, domain...)
+	if err == nil {
+		return nil
+	}
+	logPrintf(rm.logger, "Primary role manager failed to delete link %s -> %s, using fallback: '%v'", name1, name2, err)
+	return rm.fallback.DeleteLink(name1, name2, domain...)
+}
+
+// HasLink determines whether role: name1 inherits role: name2 in the
+// primary role manager, or else in the fallback one.
+func (rm *ChainRoleManager) HasLink(name1 string, name2 string, domain ...string) (bool, error) {
+	res, err := rm.primary.HasLink(name1, name2, domain...)
+	if err == nil && res {
+		return true, nil
+	}
+
+	fallbackRes, fallbackErr := rm.fallback.HasLink(name1, name2, domain...)
+	if fallbackErr != nil {
+		return res, rm.chainError(err, fallbackErr)
+	}
+	return fallbackRes, nil
+}
+
+// GetRoles gets the roles that a subject inherits from the primary role
+// manager, or from the fallback one if the primary returns none.
+func (rm *ChainRoleManager) GetRoles(name string, domain ...string) ([]string, error) {
+	return rm.list(func(m rbac.RoleManager) ([]string, error) {
+		return m.GetRoles(name, domain...)
+	})
+}
+
+// GetUsers gets the users that inherits a subject from the primary role
+// manager, or from the fallback one if the primary returns none.
+func (rm *ChainRoleManager) GetUsers(name string, domain ...string) ([]string, error) {
+	return rm.list(func(m rbac.RoleManager) ([]string, error) {
+		return m.GetUsers(name, domain...)
+	})
+}
+
+// GetImplicitRoles gets the implicit roles that a subject inherits from the
+// primary role manager, or from the fallback one if the primary returns none.
+func (rm *ChainRoleManager) GetImplicitRoles(name string, domain ...string) ([]string, error) {
+	return rm.list(func(m rbac.RoleManager) ([]string, error) {
+		return m.GetImplicitRoles(name, domain...)
+	})
+}
+
+// GetImplicitUsers gets the implicit users that inherits a subject from the
+// primary role manager, or from the fallback one if the primary returns none.
+func (rm *ChainRoleManager) GetImplicitUsers(name string, domain ...string) ([]string, error) {
+	return rm.list(func(m rbac.RoleManager) ([]string, error) {
+		return m.GetImplicitUsers(name, domain...)
+	})
+}
+
+// GetDomains gets the domains that a user has from the primary role
+// manager, or from the fallback one if the primary returns none.
+func (rm *ChainRoleManager) GetDomains(name string) ([]string, error) {
+	return rm.list(func(m rbac.RoleManager) ([]string, error) {
+		return m.GetDomains(name)
+	})
+}
+
+// GetAllDomains gets all the domains from the primary role manager, or from
+// the fallback one if the primary returns none.
+func (rm *ChainRoleManager) GetAllDomains() ([]string, error) {
+	return rm.list(func(m rbac.RoleManager) ([]string, error) {
+		return m.GetAllDomains()
+	})
+}
+
+// list returns the result of f for the primary role manager, or for the
+// fallback one if the primary returns none.
+func (rm *ChainRoleManager) list(f func(rbac.RoleManager) ([]string, error)) ([]string, error) {
+	res, err := f(rm.primary)
+	if err == nil && len(res) > 0 {
+		return res, nil
+	}
+
+	fallbackRes, fallbackErr := f(rm.fallback)
+	if fallbackErr != nil {
+		return res, rm.chainError(err, fallbackErr)
+	}
+	return fallbackRes, nil
+}
+
+// DeleteDomain deletes the data of a domain from both role managers.
+func (rm *ChainRoleManager) DeleteDomain(domain string) error {
+	err := rm.primary.DeleteDomain(domain)
+	if fallbackErr := rm.fallback.DeleteDomain(domain); err == nil {
+		err = fallbackErr
+	}
+	return err
+}
+
+// PrintRoles prints the roles of both role managers to log.
+func (rm *ChainRoleManager) PrintRoles() error {
+	err := rm.primary.PrintRoles()
+	if fallbackErr := rm.fallback.PrintRoles(); err == nil {
+		err = fallbackErr
+	}
+	return err
+}
+
+// SetLogger sets the logger of the chain and of both role managers.
+func (rm *ChainRoleManager) SetLogger(logger log.Logger) {
+	rm.logger = logger
+	rm.primary.SetLogger(logger)
+	rm.fallback.SetLogger(logger)
+}
+
+// Match matches a name with a pattern in either role manager.
+func (rm *ChainRoleManager) Match(str string, pattern string) bool {
+	return rm.primary.Match(str, pattern) || rm.fallback.Match(str, pattern)
+}
+
+// AddMatchingFunc adds the matching function to both role managers.
+func (rm *ChainRoleManager) AddMatchingFunc(name string, fn rbac.MatchingFunc) {
+	rm.primary.AddMatchingFunc(name, fn)
+	rm.fallback.AddMatchingFunc(name, fn)
+}
+
+// AddDomainMatchingFunc adds the domain matching function to both role managers.
+func (rm *ChainRoleManager) AddDomainMatchingFunc(name string, fn rbac.MatchingFunc) {
+	rm.primary.AddDomainMatchingFunc(name, fn)
+	rm.fallback.AddDomainMatchingFunc(name, fn)
+}
+
+// chainError decides the outcome when the fallback failed: a miss of the
+// primary stands, only a failure of both is reported, as the primary error.
+func (rm *ChainRoleManager) chainError(primaryErr error, fallbackErr error) error {
+	if primaryErr == nil {
+		logPrintf(rm.logger, "Fallback role manager failed: '%v'", fallbackErr)
+		return nil
+	}
+	return primaryErr
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/rbac"
+	defaultrolemanager "github.com/casbin/casbin/v2/rbac/default-role-manager"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestChainRoleManager(t *testing.T) {
+	primary := defaultrolemanager.NewRoleManager(10)
+	fallback := defaultrolemanager.NewRoleManager(10)
+	_ = primary.AddLink("alice@test.com", "Group1")
+	_ = fallback.AddLink("alice@test.com", "Admin")
+	_ = fallback.AddLink("bob@test.com", "Admin")
+
+	rm := NewChainRoleManager(primary, fallback)
+
+	testRole(t, rm, "alice@test.com", "Group1", true)
+	testRole(t, rm, "alice@test.com", "Admin", true)
+	testRole(t, rm, "bob@test.com", "Admin", true)
+	testRole(t, rm, "bob@test.com", "Group1", false)
+
+	// The primary answers when it knows the subject.
+	testPrintRoles(t, rm, "alice@test.com", []string{"Group1"})
+	// The fallback answers when the primary returns none.
+	testPrintRoles(t, rm, "bob@test.com", []string{"Admin"})
+	testPrintUsers(t, rm, "Group1", []string{"alice@test.com"})
+	testPrintRoles(t, rm, "carol@test.com", []string{})
+
+	// The fallback answers when the primary fails.
+	rm = NewChainRoleManager(failingRoleManager{primary}, fallback)
+	testRole(t, rm, "alice@test.com", "Admin", true)
+	testRole(t, rm, "alice@test.com", "Group1", false)
+	testPrintRoles(t, rm, "alice@test.com", []string{"Admin"})
+}
+
+// failingRoleManager is a role manager whose lookups fail.
+type failingRoleManager struct {
+	rbac.RoleManager
+}
+
+func (failingRoleManager) HasLink(name1 string, name2 string, domain ...string) (bool, error) {
+	return false, errors.New("failed")
+}
+
+func (failingRoleManager) GetRoles(name string, domain ...string) ([]string, error) {
+	return nil, errors.New("failed")
+}
+
+func TestChainWrites(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|bob", "bob@example.com")
+	addRole(fake, "rol_admin", "admin")
+	primary := newFakeRoleManager(t, fake)
+	fallback := defaultrolemanager.NewRoleManager(10)
+
+	// Links, e.g. the grouping rules of a loaded policy, stay local.
+	rm := NewChainRoleManager(primary, fallback)
+	if err := rm.AddLink("bob@example.com", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.CallCount("AssignUserRoles"); calls != 0 {
+		t.Errorf("role assignments: %d, supposed to be none", calls)
+	}
+	testRole(t, fallback, "bob@example.com", "admin", true)
+	testRole(t, rm, "bob@example.com", "admin", true)
+	if err := rm.DeleteLink("bob@example.com", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	testRole(t, rm, "bob@example.com", "admin", false)
+
+	e, err := casbin.NewEnforcer("examples/rbac_model.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.SetRoleManager(rm)
+	_, _ = e.AddPolicy("admin", "data1", "read")
+	_, _ = e.AddGroupingPolicy("bob@example.com", "admin")
+	if ok, _ := e.Enforce("bob@example.com", "data1", "read"); !ok {
+		t.Error("bob@example.com should be granted data1 by the policy")
+	}
+	if calls := fake.CallCount("AssignUserRoles"); calls != 0 {
+		t.Errorf("role assignments: %d, supposed to be none", calls)
+	}
+
+	// WithPrimaryWrites assigns them in Auth0.
+	fallback = defaultrolemanager.NewRoleManager(10)
+	rm = NewChainRoleManager(primary, fallback, WithPrimaryWrites())
+	if err := rm.AddLink("bob@example.com", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.CallCount("AssignUserRoles"); calls != 1 {
+		t.Errorf("role assignments: %d, supposed to be 1", calls)
+	}
+	testRole(t, fallback, "bob@example.com", "admin", false)
+	testRole(t, rm, "bob@example.com", "admin", true)
+	if err := rm.DeleteLink("bob@example.com", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.CallCount("RemoveUserRoles"); calls != 1 {
+		t.Errorf("role removals: %d, supposed to be 1", calls)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"errors"
+
+	"github.com/auth0/go-auth0/management"
+)
+
+// ManagementClient returns the authenticated Auth0 Management API client
+// used by the role manager, for one-off operations the role manager does
+// not cover. Its requests share the HTTP client of the role manager, so they
+// are retried when rate limited and counted in Stats and the API call hook
+// too. It is nil if the role manager was given a ManagementAPI, see
+// WithManagementAPI.
+func (rm *RoleManager) ManagementClient() *management.Management {
+	return rm.mgmtClient
+}
+
+// Do runs a custom Management API call with the client of ManagementClient,
+// e.g.:
+//
+//	err := rm.Do(func(m *management.Management) error {
+//		return m.User.Update(id, &management.User{Blocked: auth0.Bool(true)})
+//	})
+func (rm *RoleManager) Do(f func(m *management.Management) error) error {
+	return rm.DoCtx(context.Background(), func(ctx context.Context, m *management.Management) error {
+		return f(m)
+	})
+}
+
+// DoCtx is like Do, for calls that take ctx, e.g. through
+// management.Context(ctx). f is not called if ctx is already done.
+func (rm *RoleManager) DoCtx(ctx context.Context, f func(ctx context.Context, m *management.Management) error) error {
+	return rm.call(ctx, func() error {
+		if rm.mgmtClient == nil {
+			return errors.New("error: no Management API client, see WithManagementAPI")
+		}
+		return f(ctx, rm.mgmtClient)
+	})
+}
+
+// call runs a single Management API call of the role manager, unless ctx is
+// already done. Retries, timeouts and metrics are handled by the HTTP client
+// instead, see newHTTPClient, so that they apply to every request of the
+// Management API client, those of Do included.
+func (rm *RoleManager) call(ctx context.Context, f func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f()
+}
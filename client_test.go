@@ -0,0 +1,166 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-auth0"
+	"github.com/auth0/go-auth0/management"
+	"github.com/casbin/casbin/v2/log"
+	"github.com/casbin/casbin/v2/rbac"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestContextRoleManager(t *testing.T) {
+	var rm rbac.RoleManager = &RoleManager{}
+	if _, ok := rm.(rbac.ContextRoleManager); !ok {
+		t.Error("RoleManager should implement rbac.ContextRoleManager")
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	rm := &RoleManager{
+		nameToIDMap: map[string]string{"alice@example.com": "auth0|alice", "admin": "rol_admin"},
+		idToNameMap: map[string]string{"auth0|alice": "alice@example.com", "rol_admin": "admin"},
+		roles:       map[string]bool{"admin": true},
+		hierarchy:   newRoleHierarchy(),
+		pageSize:    defaultPageSize,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := rm.GetRolesCtx(ctx, "alice@example.com"); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetRolesCtx: %v, supposed to be %v", err, context.Canceled)
+	}
+	if _, err := rm.GetUsersCtx(ctx, "admin"); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetUsersCtx: %v, supposed to be %v", err, context.Canceled)
+	}
+	if _, err := rm.HasLinkCtx(ctx, "alice@example.com", "admin"); !errors.Is(err, context.Canceled) {
+		t.Errorf("HasLinkCtx: %v, supposed to be %v", err, context.Canceled)
+	}
+	if err := rm.AddLinkCtx(ctx, "alice@example.com", "admin"); !errors.Is(err, context.Canceled) {
+		t.Errorf("AddLinkCtx: %v, supposed to be %v", err, context.Canceled)
+	}
+	if _, err := rm.ValidateCtx(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("ValidateCtx: %v, supposed to be %v", err, context.Canceled)
+	}
+	if err := rm.ImportHierarchyCtx(ctx, strings.NewReader("g, admin, admin\n")); !errors.Is(err, context.Canceled) {
+		t.Errorf("ImportHierarchyCtx: %v, supposed to be %v", err, context.Canceled)
+	}
+	logger := &log.DefaultLogger{}
+	logger.EnableLog(true)
+	rm.SetLogger(logger)
+	if err := rm.PrintRolesCtx(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("PrintRolesCtx: %v, supposed to be %v", err, context.Canceled)
+	}
+	err := rm.DoCtx(ctx, func(ctx context.Context, m *management.Management) error {
+		t.Error("DoCtx should not call f with a canceled context")
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("DoCtx: %v, supposed to be %v", err, context.Canceled)
+	}
+}
+
+func TestDo(t *testing.T) {
+	var limited int32
+	blocked := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/users/alice", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&limited, -1) >= 0 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		blocked = r.Method == http.MethodPatch
+		fmt.Fprint(w, `{"user_id": "alice", "blocked": true}`)
+	})
+	calls := 0
+	rm := newTestRoleManager(t, mux,
+		WithMaxRetries(2),
+		WithMaxRetryWait(10*time.Millisecond),
+		WithAPICallHook(func(APICall) {
+			calls++
+		}))
+
+	// Calls get the retries and metrics of the role manager's own calls.
+	atomic.StoreInt32(&limited, 1)
+	err := rm.Do(func(m *management.Management) error {
+		return m.User.Update("alice", &management.User{Blocked: auth0.Bool(true)})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !blocked {
+		t.Error("alice should have been blocked")
+	}
+	if stats := rm.Stats(); stats.APICalls != 1 || stats.RateLimited != 1 || calls != 1 {
+		t.Errorf("stats: %+v, hook calls: %d, supposed to count 1 rate limited call", stats, calls)
+	}
+
+	// So do the calls of the client itself.
+	if rm.ManagementClient() == nil {
+		t.Fatal("the Management API client should be set")
+	}
+	if _, err := rm.ManagementClient().User.Read("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if stats := rm.Stats(); stats.APICalls != 2 || calls != 2 {
+		t.Errorf("stats: %+v, hook calls: %d, supposed to count 2 calls", stats, calls)
+	}
+
+	// DoCtx passes ctx on.
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	err = rm.DoCtx(ctx, func(ctx context.Context, m *management.Management) error {
+		if ctx.Value(key{}) != "value" {
+			t.Error("DoCtx should pass ctx to f")
+		}
+		_, err := m.User.Read("alice", management.Context(ctx))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Errors of f are returned.
+	failed := errors.New("failed")
+	if err := rm.Do(func(m *management.Management) error { return failed }); err != failed {
+		t.Errorf("Do: %v, supposed to be %v", err, failed)
+	}
+}
+
+func TestDoWithoutClient(t *testing.T) {
+	rm := newFakeRoleManager(t, auth0test.New())
+	if rm.ManagementClient() != nil {
+		t.Error("there should be no Management API client with WithManagementAPI")
+	}
+	err := rm.Do(func(m *management.Management) error {
+		t.Error("Do should not call f without a client")
+		return nil
+	})
+	if err == nil {
+		t.Error("Do should fail without a client")
+	}
+}
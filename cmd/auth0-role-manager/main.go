@@ -0,0 +1,154 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command auth0-role-manager provides maintenance tasks for the Auth0 role
+// manager.
+//
+// Usage:
+//
+//	auth0-role-manager lint [flags]
+//	auth0-role-manager export [flags]
+//
+// The lint subcommand validates the local role hierarchy against the Auth0
+// tenant and prints a JSON report. It exits with status 1 if issues were
+// found and 2 on errors.
+//
+// The export subcommand prints the Auth0 role assignments and the local
+// role hierarchy as casbin "g" policy rules, for enforcement fully in
+// memory. It exits with status 2 on errors.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	auth0rolemanager "github.com/olvesh/auth0-role-manager/v2"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "lint":
+		os.Exit(lint(os.Args[2:]))
+	case "export":
+		os.Exit(export(os.Args[2:]))
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: auth0-role-manager lint|export [flags]")
+}
+
+// connectFlags defines the flags of fs connecting to the Auth0 tenant, and
+// returns a function creating the role manager once fs is parsed.
+func connectFlags(fs *flag.FlagSet) func(opts ...auth0rolemanager.Option) (*auth0rolemanager.RoleManager, error) {
+	clientID := fs.String("client-id", os.Getenv("AUTH0_CLIENT_ID"), "Auth0 client ID (default $AUTH0_CLIENT_ID)")
+	clientSecret := fs.String("client-secret", os.Getenv("AUTH0_CLIENT_SECRET"), "Auth0 client secret (default $AUTH0_CLIENT_SECRET)")
+	tenant := fs.String("tenant", os.Getenv("AUTH0_TENANT"), "Auth0 tenant name (default $AUTH0_TENANT)")
+	domain := fs.String("domain", os.Getenv("AUTH0_DOMAIN"), "Auth0 domain, for regional tenants and custom domains (default $AUTH0_DOMAIN)")
+	hierarchy := fs.String("hierarchy", "", "file holding the local role hierarchy")
+
+	return func(opts ...auth0rolemanager.Option) (*auth0rolemanager.RoleManager, error) {
+		if *domain != "" {
+			opts = append(opts, auth0rolemanager.WithDomain(*domain))
+		}
+		if *hierarchy != "" {
+			opts = append(opts, auth0rolemanager.WithHierarchyStore(auth0rolemanager.NewFileHierarchyStore(*hierarchy)))
+		}
+		m, err := auth0rolemanager.NewRoleManagerWithOptions(*clientID, *clientSecret, *tenant, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return m.(*auth0rolemanager.RoleManager), nil
+	}
+}
+
+func lint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	connect := connectFlags(fs)
+	maxLevel := fs.Int("max-hierarchy-level", 0, "longest inheritance chain accepted (default 10)")
+	_ = fs.Parse(args)
+
+	opts := []auth0rolemanager.Option{}
+	if *maxLevel != 0 {
+		opts = append(opts, auth0rolemanager.WithMaxHierarchyLevel(*maxLevel))
+	}
+	rm, err := connect(opts...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	report, err := rm.Validate()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
+
+func export(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	connect := connectFlags(fs)
+	organizations := fs.Bool("organizations", false, "export the role assignments of every Auth0 Organization, with its name as domain")
+	_ = fs.Parse(args)
+
+	opts := []auth0rolemanager.Option{}
+	if *organizations {
+		opts = append(opts, auth0rolemanager.WithOrganizations())
+	}
+	rm, err := connect(opts...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	rules, err := rm.DumpGroupingPolicies()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	for _, rule := range rules {
+		_ = w.Write(append([]string{"g"}, rule...))
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return 0
+}
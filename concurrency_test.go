@@ -0,0 +1,68 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/casbin/casbin/v2/log"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestConcurrentUse(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	addRole(fake, "rol_admin", "admin")
+	addRole(fake, "rol_editor", "editor")
+	assignRoles(t, fake, "auth0|alice", "rol_editor")
+	rm := newFakeRoleManager(t, fake)
+
+	// Run with -race to detect unsynchronized accesses.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				switch (i + j) % 6 {
+				case 0:
+					if err := rm.Refresh(); err != nil {
+						t.Error(err)
+					}
+				case 1:
+					_ = rm.AddLink("editor", "admin")
+				case 2:
+					_ = rm.DeleteLink("editor", "admin")
+				case 3:
+					rm.InvalidateUser("alice@example.com")
+				case 4:
+					rm.SetLogger(&log.DefaultLogger{})
+				default:
+					if _, err := rm.HasLink("alice@example.com", "editor"); err != nil {
+						t.Error(err)
+					}
+					if _, err := rm.GetUsers("admin"); err != nil {
+						t.Error(err)
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	testRole(t, rm, "alice@example.com", "editor", true)
+}
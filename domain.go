@@ -0,0 +1,94 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// DomainResolver maps a domain alias used in policies to the identifier
+// known by Auth0. It is consulted for aliases missing from the alias table.
+type DomainResolver func(alias string) (string, error)
+
+// SetDomainAliases replaces the domain alias table, e.g. "acme" -> "org_9f3kQ2...".
+func (rm *RoleManager) SetDomainAliases(aliases map[string]string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.domainAliases = map[string]string{}
+	for alias, domain := range aliases {
+		rm.domainAliases[alias] = domain
+	}
+}
+
+// AddDomainAlias registers a single domain alias.
+func (rm *RoleManager) AddDomainAlias(alias string, domain string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if rm.domainAliases == nil {
+		rm.domainAliases = map[string]string{}
+	}
+	rm.domainAliases[alias] = domain
+}
+
+// LoadDomainAliases reads the domain alias table from a JSON object of
+// alias -> domain pairs.
+func (rm *RoleManager) LoadDomainAliases(r io.Reader) error {
+	aliases := map[string]string{}
+	if err := json.NewDecoder(r).Decode(&aliases); err != nil {
+		return err
+	}
+	rm.SetDomainAliases(aliases)
+	return nil
+}
+
+// SetDomainResolver sets the callback used for aliases that are not in the
+// alias table. A nil resolver leaves such domains unchanged.
+func (rm *RoleManager) SetDomainResolver(resolver DomainResolver) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.domainResolver = resolver
+}
+
+// resolveDomain returns the Auth0 identifier for the optional domain
+// argument of the domain-aware methods, or "" when no domain is given.
+func (rm *RoleManager) resolveDomain(domain ...string) (string, error) {
+	if len(domain) == 0 {
+		return "", nil
+	}
+	if len(domain) > 1 {
+		return "", errors.New("error: domain should be 1 parameter")
+	}
+
+	rm.mu.RLock()
+	resolved, ok := rm.domainAliases[domain[0]]
+	if !ok {
+		resolved, ok = rm.matchDomainAlias(domain[0])
+	}
+	resolver := rm.domainResolver
+	rm.mu.RUnlock()
+
+	if ok {
+		return resolved, nil
+	}
+	if resolver != nil {
+		return resolver(domain[0])
+	}
+	return domain[0], nil
+}
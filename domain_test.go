@@ -0,0 +1,59 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolveDomain(t *testing.T) {
+	rm := &RoleManager{}
+	rm.SetDomainAliases(map[string]string{"acme": "org_9f3kQ2"})
+
+	if d, _ := rm.resolveDomain(); d != "" {
+		t.Errorf("no domain: %s, supposed to be empty", d)
+	}
+	if d, _ := rm.resolveDomain("acme"); d != "org_9f3kQ2" {
+		t.Errorf("acme: %s, supposed to be org_9f3kQ2", d)
+	}
+	if d, _ := rm.resolveDomain("org_other"); d != "org_other" {
+		t.Errorf("org_other: %s, supposed to be unchanged", d)
+	}
+	if _, err := rm.resolveDomain("a", "b"); err == nil {
+		t.Error("two domains should be rejected")
+	}
+
+	rm.SetDomainResolver(func(alias string) (string, error) {
+		if alias == "globex" {
+			return "org_globex", nil
+		}
+		return "", errors.New("unknown domain alias")
+	})
+	if d, _ := rm.resolveDomain("globex"); d != "org_globex" {
+		t.Errorf("globex: %s, supposed to be org_globex", d)
+	}
+	if _, err := rm.resolveDomain("initech"); err == nil {
+		t.Error("initech: resolver error should be returned")
+	}
+
+	if err := rm.LoadDomainAliases(strings.NewReader(`{"initech": "org_initech"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := rm.resolveDomain("initech"); d != "org_initech" {
+		t.Errorf("initech: %s, supposed to be org_initech", d)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// GetAllRoles gets the names of all the roles in the (ID, name) mapping,
+// sorted.
+func (rm *RoleManager) GetAllRoles() ([]string, error) {
+	return rm.GetAllRolesCtx(context.Background())
+}
+
+// GetAllRolesCtx is like GetAllRoles, with ctx bounding the Management API calls.
+func (rm *RoleManager) GetAllRolesCtx(ctx context.Context) ([]string, error) {
+	rm.refreshIfStale()
+
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	return sortedKeys(rm.roles), nil
+}
+
+// GetAllUsers gets the names of all the users in the (ID, name) mapping,
+// sorted.
+func (rm *RoleManager) GetAllUsers() ([]string, error) {
+	return rm.GetAllUsersCtx(context.Background())
+}
+
+// GetAllUsersCtx is like GetAllUsers, with ctx bounding the Management API calls.
+func (rm *RoleManager) GetAllUsersCtx(ctx context.Context) ([]string, error) {
+	rm.refreshIfStale()
+
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	res := make([]string, 0, len(rm.profiles))
+	for id := range rm.profiles {
+		if name, ok := rm.idToNameMap[id]; ok {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// GetAllAssignments gets the roles assigned to every user in Auth0, as a map
+// of user name to sorted role names. It lists the users of every role in
+// the mapping, so it costs one paginated call per role. Roles inherited
+// through the local role hierarchy and synthetic roles are not included.
+func (rm *RoleManager) GetAllAssignments() (map[string][]string, error) {
+	return rm.GetAllAssignmentsCtx(context.Background())
+}
+
+// GetAllAssignmentsCtx is like GetAllAssignments, with ctx bounding the Management API calls.
+func (rm *RoleManager) GetAllAssignmentsCtx(ctx context.Context) (map[string][]string, error) {
+	roles, err := rm.GetAllRolesCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string][]string{}
+	for _, role := range roles {
+		users, err := rm.getAuth0RoleUsers(ctx, role, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			res[user.Name] = append(res[user.Name], role)
+		}
+	}
+	return res, nil
+}
+
+// PrintRoles prints the Auth0 role assignments of all the users and the
+// links of the local role hierarchy, one "name < roles" line per user and
+// role: to the leveled logger at LevelInfo if set, see WithLeveledLogger,
+// and otherwise to the casbin logger if enabled. User names are redacted
+// with WithPIIRedaction.
+func (rm *RoleManager) PrintRoles() error {
+	return rm.PrintRolesCtx(context.Background())
+}
+
+// PrintRolesCtx is like PrintRoles, with ctx bounding the Management API calls.
+func (rm *RoleManager) PrintRolesCtx(ctx context.Context) error {
+	logger, leveledLogger := rm.loggers()
+	if leveledLogger == nil && (logger == nil || !logger.IsEnabled()) {
+		return nil
+	}
+
+	assignments, err := rm.GetAllAssignmentsCtx(ctx)
+	if err != nil {
+		return err
+	}
+
+	lines := []string{}
+	for _, user := range sortedNames(assignments) {
+		lines = append(lines, roleLine(rm.pii(user), assignments[user]))
+	}
+
+	rm.mu.RLock()
+	for _, role := range sortedNames(rm.hierarchy.parents) {
+		if parents := sortedKeys(rm.hierarchy.parents[role]); len(parents) > 0 {
+			lines = append(lines, roleLine(role, parents))
+		}
+	}
+	rm.mu.RUnlock()
+
+	if leveledLogger != nil {
+		for _, line := range lines {
+			rm.logf(LevelInfo, "%s", line)
+		}
+		return nil
+	}
+	logger.LogRole(lines)
+	return nil
+}
+
+// roleLine formats the roles of name as casbin does, e.g. "alice < admin"
+// or "alice < (admin, editor)".
+func roleLine(name string, roles []string) string {
+	if len(roles) == 1 {
+		return name + " < " + roles[0]
+	}
+	return name + " < (" + strings.Join(roles, ", ") + ")"
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
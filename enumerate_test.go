@@ -0,0 +1,121 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/casbin/casbin/v2/log"
+	"github.com/casbin/casbin/v2/util"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+type roleLogger struct {
+	log.DefaultLogger
+	roles []string
+}
+
+func (l *roleLogger) LogRole(roles []string) {
+	l.roles = roles
+}
+
+func TestEnumeration(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	addUser(fake, "auth0|bob", "bob@example.com")
+	addUser(fake, "auth0|carol", "carol@example.com")
+	addRole(fake, "rol_admin", "admin")
+	addRole(fake, "rol_editor", "editor")
+	assignRoles(t, fake, "auth0|alice", "rol_editor")
+	assignRoles(t, fake, "auth0|bob", "rol_admin", "rol_editor")
+	// Pages of one user each.
+	rm := newFakeRoleManager(t, fake, WithPageSize(1))
+	_ = rm.AddLink("admin", "editor")
+
+	if roles, _ := rm.GetAllRoles(); !util.ArrayEquals(roles, []string{"admin", "editor"}) {
+		t.Errorf("roles: %s, supposed to be [admin editor]", roles)
+	}
+	users, _ := rm.GetAllUsers()
+	if !util.ArrayEquals(users, []string{"alice@example.com", "bob@example.com", "carol@example.com"}) {
+		t.Errorf("users: %s, supposed to be [alice@example.com bob@example.com carol@example.com]", users)
+	}
+
+	assignments, err := rm.GetAllAssignments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"alice@example.com": {"editor"},
+		"bob@example.com":   {"admin", "editor"},
+	}
+	if !reflect.DeepEqual(assignments, expected) {
+		t.Errorf("assignments: %v, supposed to be %v", assignments, expected)
+	}
+
+	logger := &roleLogger{}
+	logger.EnableLog(true)
+	rm.SetLogger(logger)
+	if err := rm.PrintRoles(); err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{"alice@example.com < editor", "bob@example.com < (admin, editor)", "admin < editor"}
+	if !util.ArrayEquals(logger.roles, lines) {
+		t.Errorf("printed roles: %q, supposed to be %q", logger.roles, lines)
+	}
+}
+
+func TestPrintRolesLeveled(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	addRole(fake, "rol_admin", "admin")
+	addRole(fake, "rol_editor", "editor")
+	assignRoles(t, fake, "auth0|alice", "rol_editor")
+	logger := &recordingLogger{minLevel: LevelInfo}
+	rm := newFakeRoleManager(t, fake, WithLeveledLogger(logger), WithPIIRedaction())
+	_ = rm.AddLink("admin", "editor")
+
+	logger.messages = nil
+	if err := rm.PrintRoles(); err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{"info: a***@example.com < editor", "info: admin < editor"}
+	if !util.ArrayEquals(logger.messages, lines) {
+		t.Errorf("printed roles: %q, supposed to be %q", logger.messages, lines)
+	}
+}
+
+func TestRoleUsersCheckpoints(t *testing.T) {
+	fake := auth0test.New()
+	addRole(fake, "rol_editor", "editor")
+	rm := newFakeRoleManager(t, fake)
+
+	// More users than can be paged through.
+	const users = 1050
+	for i := 0; i < users; i++ {
+		id := fmt.Sprintf("auth0|%d", i)
+		addUser(fake, id, fmt.Sprintf("user%d@example.com", i))
+		assignRoles(t, fake, id, "rol_editor")
+	}
+	res, err := rm.GetUsers("editor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != users || res[users-1] != "user1049@example.com" {
+		t.Errorf("%d users of editor, supposed to be %d", len(res), users)
+	}
+}
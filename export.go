@@ -0,0 +1,179 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// DumpGroupingPolicies gets the Auth0 role assignments of all the users and
+// the links of the local role hierarchy as casbin grouping policy rules,
+// e.g. ["alice@example.com", "admin"] and ["admin", "editor"], so that an
+// enforcer using casbin's default role manager can enforce them in memory.
+// With organizations enabled, the rules are those of every organization,
+// with its name as domain, e.g. ["alice@example.com", "admin", "acme"].
+//
+// The users of every role are listed, or the roles of every member of
+// every organization, so a dump costs many calls for large tenants.
+// Synthetic roles are not included.
+func (rm *RoleManager) DumpGroupingPolicies() ([][]string, error) {
+	return rm.DumpGroupingPoliciesCtx(context.Background())
+}
+
+// DumpGroupingPoliciesCtx is like DumpGroupingPolicies, with ctx bounding the Management API calls.
+func (rm *RoleManager) DumpGroupingPoliciesCtx(ctx context.Context) ([][]string, error) {
+	if !rm.organizationsEnabled() {
+		assignments, err := rm.GetAllAssignmentsCtx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return rm.groupingRules(assignments, ""), nil
+	}
+
+	domains, err := rm.GetAllDomainsCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(domains)
+	res := [][]string{}
+	for _, domain := range domains {
+		assignments, err := rm.organizationAssignments(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, rm.groupingRules(assignments, domain)...)
+	}
+	return res, nil
+}
+
+// organizationAssignments gets the roles assigned to every member of an
+// organization, as a map of user name to role names.
+func (rm *RoleManager) organizationAssignments(ctx context.Context, domain string) (map[string][]string, error) {
+	orgID, err := rm.organizationID(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	members, err := rm.getOrganizationMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string][]string{}
+	for _, member := range members {
+		roles, err := rm.getOrganizationMemberRoles(ctx, orgID, member.ID)
+		if err != nil {
+			return nil, err
+		}
+		res[member.Name] = append(res[member.Name], roleInfoNames(roles)...)
+	}
+	return res, nil
+}
+
+// groupingRules returns the rules of the assignments and of the local role
+// hierarchy, sorted, with domain as third field if not empty.
+func (rm *RoleManager) groupingRules(assignments map[string][]string, domain string) [][]string {
+	rule := func(name, role string) []string {
+		if domain == "" {
+			return []string{name, role}
+		}
+		return []string{name, role, domain}
+	}
+
+	res := [][]string{}
+	for _, user := range sortedNames(assignments) {
+		roles := append([]string{}, assignments[user]...)
+		sort.Strings(roles)
+		for _, role := range roles {
+			res = append(res, rule(user, role))
+		}
+	}
+
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	for _, role := range sortedNames(rm.hierarchy.parents) {
+		for _, parent := range sortedKeys(rm.hierarchy.parents[role]) {
+			res = append(res, rule(role, parent))
+		}
+	}
+	return res
+}
+
+// GroupingPolicyEnforcer is the part of a casbin enforcer updated by
+// SyncGroupingPolicies. It is implemented by casbin.Enforcer and
+// casbin.SyncedEnforcer.
+type GroupingPolicyEnforcer interface {
+	GetNamedGroupingPolicy(ptype string) ([][]string, error)
+	AddNamedGroupingPolicies(ptype string, rules [][]string) (bool, error)
+	RemoveNamedGroupingPolicies(ptype string, rules [][]string) (bool, error)
+}
+
+// SyncGroupingPolicies replaces the grouping policy rules of type ptype of
+// e, e.g. "g", with those of DumpGroupingPolicies. Only the rules that
+// changed are added and removed, so it can be called periodically to
+// resync an enforcer enforcing fully in memory. e should use casbin's
+// default role manager for ptype, not this one.
+func (rm *RoleManager) SyncGroupingPolicies(e GroupingPolicyEnforcer, ptype string) error {
+	return rm.SyncGroupingPoliciesCtx(context.Background(), e, ptype)
+}
+
+// SyncGroupingPoliciesCtx is like SyncGroupingPolicies, with ctx bounding the Management API calls.
+func (rm *RoleManager) SyncGroupingPoliciesCtx(ctx context.Context, e GroupingPolicyEnforcer, ptype string) error {
+	rules, err := rm.DumpGroupingPoliciesCtx(ctx)
+	if err != nil {
+		return err
+	}
+	current, err := e.GetNamedGroupingPolicy(ptype)
+	if err != nil {
+		return err
+	}
+
+	key := func(rule []string) string {
+		return strings.Join(rule, "\x00")
+	}
+	want := map[string]bool{}
+	for _, rule := range rules {
+		want[key(rule)] = true
+	}
+	have := map[string]bool{}
+	removed := [][]string{}
+	for _, rule := range current {
+		have[key(rule)] = true
+		if !want[key(rule)] {
+			removed = append(removed, rule)
+		}
+	}
+	added := [][]string{}
+	for _, rule := range rules {
+		if !have[key(rule)] {
+			added = append(added, rule)
+		}
+	}
+
+	if len(removed) > 0 {
+		if _, err := e.RemoveNamedGroupingPolicies(ptype, removed); err != nil {
+			return err
+		}
+	}
+	if len(added) > 0 {
+		if _, err := e.AddNamedGroupingPolicies(ptype, added); err != nil {
+			return err
+		}
+	}
+	rm.logf(LevelInfo, "Synced %s rules: %d added, %d removed", ptype, len(added), len(removed))
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestGroupingPolicies(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	addUser(fake, "auth0|bob", "bob@example.com")
+	addRole(fake, "rol_admin", "admin")
+	addRole(fake, "rol_editor", "editor")
+	assignRoles(t, fake, "auth0|alice", "rol_editor")
+	assignRoles(t, fake, "auth0|bob", "rol_admin")
+	rm := newFakeRoleManager(t, fake)
+	_ = rm.AddLink("admin", "editor")
+
+	want := [][]string{
+		{"alice@example.com", "editor"},
+		{"bob@example.com", "admin"},
+		{"admin", "editor"},
+	}
+	rules, err := rm.DumpGroupingPolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("rules: %v, supposed to be %v", rules, want)
+	}
+
+	e, err := casbin.NewEnforcer("examples/rbac_model.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = e.AddPolicy("editor", "data1", "read")
+	_, _ = e.AddGroupingPolicy("carol@example.com", "admin")
+	_, _ = e.AddGroupingPolicy("alice@example.com", "editor")
+
+	if err := rm.SyncGroupingPolicies(e, "g"); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := e.GetGroupingPolicy()
+	if len(got) != len(want) {
+		t.Errorf("enforcer rules: %v, supposed to be %v", got, want)
+	}
+	for _, c := range []struct {
+		user string
+		ok   bool
+	}{
+		{"alice@example.com", true},
+		{"bob@example.com", true},
+		{"carol@example.com", false},
+	} {
+		if ok, _ := e.Enforce(c.user, "data1", "read"); ok != c.ok {
+			t.Errorf("%s, data1, read: %t, supposed to be %t", c.user, ok, c.ok)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"strings"
+)
+
+// SetUserFields restricts the user fields fetched when loading the (ID,
+// name) mapping, which cuts bandwidth and speeds up the loading of large
+// tenants. user_id, email and the user identity attribute are always
+// fetched, as well as the fields needed by the account state roles and the
+// metadata rules. Without a
+// restriction, full user profiles are fetched, as SetSyntheticRoleProvider
+// and rich results may need them.
+//
+// The restriction applies to the loads of the mapping done after the call.
+func (rm *RoleManager) SetUserFields(fields ...string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.fields = append([]string{}, fields...)
+}
+
+// userFields returns the user fields to fetch, or nil for full profiles.
+// rm.mu must be held.
+func (rm *RoleManager) userFields() []string {
+	if rm.fields == nil {
+		return nil
+	}
+
+	res := []string{}
+	seen := map[string]bool{}
+	add := func(fields ...string) {
+		for _, field := range fields {
+			if !seen[field] {
+				seen[field] = true
+				res = append(res, field)
+			}
+		}
+	}
+
+	add("user_id", "email")
+	if attribute := rm.searchAttribute(); attribute != "" {
+		add(attribute)
+	}
+	add(rm.fields...)
+	if rm.accountStateRoles {
+		add("blocked", "email_verified")
+	}
+	for _, rule := range rm.metadataRules {
+		add(strings.SplitN(rule.Path, ".", 2)[0])
+	}
+	return res
+}
+
+// SetUserQuery restricts the users loaded into the (ID, name) mapping and
+// looked up on demand to those matching a query in the Auth0 Lucene syntax,
+// e.g. "app_metadata.tenant:acme", for apps caring about a segment of the
+// tenant only. Auth0 returns at most 1000 users per query.
+//
+// The restriction applies to the loads of the mapping done after the call.
+func (rm *RoleManager) SetUserQuery(query string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.query = query
+}
+
+// SetUserConnections restricts the users loaded into the (ID, name) mapping
+// and looked up on demand to those having an identity in any of the given
+// connections, e.g. "Username-Password-Authentication". It combines with
+// SetUserQuery.
+//
+// The restriction applies to the loads of the mapping done after the call.
+func (rm *RoleManager) SetUserConnections(connections ...string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.connections = append([]string{}, connections...)
+}
+
+// userQuery returns the query restricting the users, or "" if all users are
+// loaded. rm.mu must be held.
+func (rm *RoleManager) userQuery() string {
+	terms := []string{}
+	if len(rm.connections) > 0 {
+		connections := make([]string, 0, len(rm.connections))
+		for _, connection := range rm.connections {
+			connections = append(connections, "identities.connection:"+quoteValue(connection))
+		}
+		terms = append(terms, "("+strings.Join(connections, " OR ")+")")
+	}
+	if rm.query != "" {
+		terms = append(terms, "("+rm.query+")")
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// querySpecials are the special characters of the Lucene query syntax of
+// Auth0, see https://auth0.com/docs/manage-users/user-search/user-search-query-syntax.
+const querySpecials = `+-&|!(){}[]^"~*?:\/`
+
+// quoteValue quotes a value of a user search query, escaping the special
+// characters so that it is matched literally.
+func quoteValue(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		if strings.ContainsRune(querySpecials, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,77 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/casbin/casbin/v2/util"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestUserFields(t *testing.T) {
+	rm := &RoleManager{}
+	if fields := rm.userFields(); fields != nil {
+		t.Errorf("fields: %s, supposed to be nil for full profiles", fields)
+	}
+
+	rm.SetUserFields()
+	if fields := rm.userFields(); !util.ArrayEquals(fields, []string{"user_id", "email"}) {
+		t.Errorf("fields: %s, supposed to be [user_id email]", fields)
+	}
+
+	rm.SetUserFields("name", "email")
+	rm.EnableAccountStateRoles(true)
+	_ = rm.SetMetadataRules(MetadataRule{Path: "app_metadata.beta", Value: "true", Role: "beta-tester"})
+	expected := []string{"user_id", "email", "name", "blocked", "email_verified", "app_metadata"}
+	if fields := rm.userFields(); !util.ArrayEquals(fields, expected) {
+		t.Errorf("fields: %s, supposed to be %s", fields, expected)
+	}
+}
+
+func TestUserQuery(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	rm := newFakeRoleManager(t, fake,
+		WithUserConnections("Username-Password-Authentication", "google-oauth2"),
+		WithUserQuery("app_metadata.tenant:acme"))
+
+	query := `(identities.connection:"Username\-Password\-Authentication" OR identities.connection:"google\-oauth2") AND (app_metadata.tenant:acme)`
+	if queries := userQueries(fake); len(queries) != 1 || queries[0] != query {
+		t.Errorf("load queries: %q, supposed to be [%q]", queries, query)
+	}
+
+	// Lookups of users missing from the mapping are restricted too.
+	if _, err := rm.userID(context.Background(), "bob@example.com"); err == nil {
+		t.Error("bob@example.com should not have been found")
+	}
+	expected := `email:"bob@example.com" AND ` + query
+	if queries := userQueries(fake)[1:]; len(queries) != 1 || queries[0] != expected {
+		t.Errorf("lookup queries: %q, supposed to be [%q]", queries, expected)
+	}
+}
+
+// userQueries returns the queries of the user searches made on fake.
+func userQueries(fake *auth0test.Fake) []string {
+	var queries []string
+	for _, c := range fake.Calls() {
+		if c.Method == "ListUsers" {
+			queries = append(queries, c.Options.Query)
+		}
+	}
+	return queries
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/auth0/go-auth0"
+	"github.com/auth0/go-auth0/management"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+var _ ManagementAPI = (*auth0test.Fake)(nil)
+
+// newFakeRoleManager returns a role manager calling fake, with the (ID,
+// name) mapping loaded from it.
+func newFakeRoleManager(t *testing.T, fake *auth0test.Fake, opts ...Option) *RoleManager {
+	t.Helper()
+	rm, err := newRoleManager("", "", "", append([]Option{WithManagementAPI(fake)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return rm
+}
+
+// addUser adds a user with an email to fake.
+func addUser(fake *auth0test.Fake, id string, email string) {
+	fake.AddUser(&management.User{ID: auth0.String(id), Email: auth0.String(email)})
+}
+
+// addRole adds a role to fake.
+func addRole(fake *auth0test.Fake, id string, name string) {
+	fake.AddRole(&management.Role{ID: auth0.String(id), Name: auth0.String(name)})
+}
+
+// assignRoles assigns roles to a user of fake.
+func assignRoles(t *testing.T, fake *auth0test.Fake, userID string, roleIDs ...string) {
+	t.Helper()
+	if err := fake.AssignUserRoles(context.Background(), userID, roleIDs); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestRoleManager returns a role manager calling a Management API
+// served by handler, with an empty (ID, name) mapping, for the tests of the
+// HTTP handling of the calls. Other tests use newFakeRoleManager.
+func newTestRoleManager(t *testing.T, handler http.Handler, opts ...Option) *RoleManager {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	rm, err := newRoleManager("client_id", "client_secret", srv.Listener.Addr().String(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm.mgmtClient, err = management.New(srv.Listener.Addr().String(),
+		management.WithClient(rm.newHTTPClient()),
+		management.WithInsecure(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm.api = newManagementAPI(rm.mgmtClient, rm.newBaseHTTPClient())
+	return rm
+}
@@ -0,0 +1,267 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CycleError is returned when a link would create a cycle in the role
+// hierarchy. Cycle lists the roles on the cycle, starting and ending with
+// the same role.
+type CycleError struct {
+	Cycle []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("error: link would create a cycle: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// roleHierarchy is the local role -> role inheritance graph. Auth0 has no
+// nested roles, so links between two roles are kept here instead. The
+// hierarchy of a RoleManager is guarded by its mutex.
+type roleHierarchy struct {
+	// parents maps a role to the roles it directly inherits.
+	parents map[string]map[string]bool
+	// children maps a role to the roles directly inheriting it.
+	children map[string]map[string]bool
+}
+
+func newRoleHierarchy() *roleHierarchy {
+	return &roleHierarchy{
+		parents:  map[string]map[string]bool{},
+		children: map[string]map[string]bool{},
+	}
+}
+
+// newRoleHierarchyFromEdges builds a hierarchy from imported edges. Edges
+// closing a cycle are dropped and logged to logf if not nil, so that
+// traversals stay well-defined.
+func newRoleHierarchyFromEdges(edges []Edge, logf func(level Level, format string, v ...interface{})) *roleHierarchy {
+	h := newRoleHierarchy()
+	for _, e := range edges {
+		if err := h.checkEdge(e.Role, e.Parent); err != nil {
+			if logf != nil {
+				logf(LevelWarn, "Dropping role link %s -> %s: %v", e.Role, e.Parent, err)
+			}
+			continue
+		}
+		h.addEdge(e.Role, e.Parent)
+	}
+	return h
+}
+
+// SetHierarchyStore sets the store persisting the local role hierarchy and
+// replaces the current hierarchy with the edges loaded from it.
+func (rm *RoleManager) SetHierarchyStore(store HierarchyStore) error {
+	edges, err := store.LoadEdges()
+	if err != nil {
+		return err
+	}
+
+	hierarchy := newRoleHierarchyFromEdges(edges, rm.logf)
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.hierarchy = hierarchy
+	rm.store = store
+	return nil
+}
+
+// SetMaxHierarchyLevel sets the number of links of the local role hierarchy
+// followed when resolving inherited roles and users, 10 by default. The
+// assignment of a role to a user in Auth0 does not count as a link.
+func (rm *RoleManager) SetMaxHierarchyLevel(level int) error {
+	if level < 1 {
+		return errors.New("error: max hierarchy level should be at least 1")
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.maxHierarchyLevel = level
+	return nil
+}
+
+// hierarchyLevels returns the number of links of the local role hierarchy
+// to follow. rm.mu must be held.
+func (rm *RoleManager) hierarchyLevels() int {
+	if rm.maxHierarchyLevel == 0 {
+		return defaultMaxHierarchyLevel
+	}
+	return rm.maxHierarchyLevel
+}
+
+// addRoleLink adds a link to the local role hierarchy, writing it through
+// to the hierarchy store first.
+func (rm *RoleManager) addRoleLink(role string, parent string) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if err := rm.hierarchy.checkEdge(role, parent); err != nil {
+		return err
+	}
+	if rm.store != nil {
+		if err := rm.store.AddEdge(Edge{Role: role, Parent: parent}); err != nil {
+			return err
+		}
+	}
+	rm.hierarchy.addEdge(role, parent)
+	return nil
+}
+
+// deleteRoleLink deletes a link from the local role hierarchy, deleting it
+// from the hierarchy store first.
+func (rm *RoleManager) deleteRoleLink(role string, parent string) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if !rm.hierarchy.hasEdge(role, parent) {
+		return errors.New("error: link between name1 and name2 does not exist")
+	}
+	if rm.store != nil {
+		if err := rm.store.RemoveEdge(Edge{Role: role, Parent: parent}); err != nil {
+			return err
+		}
+	}
+	rm.hierarchy.removeEdge(role, parent)
+	return nil
+}
+
+// addEdge records that role inherits parent.
+func (h *roleHierarchy) addEdge(role string, parent string) {
+	if h.parents[role] == nil {
+		h.parents[role] = map[string]bool{}
+	}
+	if h.children[parent] == nil {
+		h.children[parent] = map[string]bool{}
+	}
+	h.parents[role][parent] = true
+	h.children[parent][role] = true
+}
+
+// removeEdge deletes the link between role and parent, if any.
+func (h *roleHierarchy) removeEdge(role string, parent string) {
+	delete(h.parents[role], parent)
+	if len(h.parents[role]) == 0 {
+		delete(h.parents, role)
+	}
+	delete(h.children[parent], role)
+	if len(h.children[parent]) == 0 {
+		delete(h.children, parent)
+	}
+}
+
+// checkEdge returns a *CycleError if linking role to parent would create
+// a cycle, which is the case when parent already inherits role.
+func (h *roleHierarchy) checkEdge(role string, parent string) error {
+	if role == parent {
+		return &CycleError{Cycle: []string{role, parent}}
+	}
+	if path := h.path(parent, role); path != nil {
+		return &CycleError{Cycle: append([]string{role}, path...)}
+	}
+	return nil
+}
+
+// path returns the shortest inheritance chain from role to ancestor, both
+// included, or nil if role does not inherit ancestor.
+func (h *roleHierarchy) path(role string, ancestor string) []string {
+	prev := map[string]string{role: ""}
+	queue := []string{role}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if name == ancestor {
+			res := []string{}
+			for ; name != ""; name = prev[name] {
+				res = append([]string{name}, res...)
+			}
+			return res
+		}
+		for _, next := range sortedKeys(h.parents[name]) {
+			if _, ok := prev[next]; !ok {
+				prev[next] = name
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// hasEdge determines whether role directly inherits parent.
+func (h *roleHierarchy) hasEdge(role string, parent string) bool {
+	return h.parents[role][parent]
+}
+
+// ancestors returns all the roles that role inherits transitively.
+func (h *roleHierarchy) ancestors(role string) []string {
+	return walk(h.parents, role, -1)
+}
+
+// ancestorsWithin returns the roles that role inherits through at most
+// levels links.
+func (h *roleHierarchy) ancestorsWithin(role string, levels int) []string {
+	return walk(h.parents, role, levels)
+}
+
+// descendants returns all the roles inheriting role transitively.
+func (h *roleHierarchy) descendants(role string) []string {
+	return walk(h.children, role, -1)
+}
+
+// descendantsWithin returns the roles inheriting role through at most
+// levels links.
+func (h *roleHierarchy) descendantsWithin(role string, levels int) []string {
+	return walk(h.children, role, levels)
+}
+
+// walk does a breadth-first traversal of edges starting at start, which is
+// not part of the result, following at most levels edges from it, or any
+// number if levels is negative. Every node is visited once, so cycles are
+// harmless.
+func walk(edges map[string]map[string]bool, start string, levels int) []string {
+	res := []string{}
+	visited := map[string]bool{start: true}
+	queue := []string{start}
+	for level := 0; len(queue) > 0 && level != levels; level++ {
+		next := []string{}
+		for _, name := range queue {
+			for _, n := range sortedKeys(edges[name]) {
+				if visited[n] {
+					continue
+				}
+				visited[n] = true
+				res = append(res, n)
+				next = append(next, n)
+			}
+		}
+		queue = next
+	}
+	return res
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
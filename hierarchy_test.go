@@ -0,0 +1,142 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"testing"
+
+	"github.com/casbin/casbin/v2/util"
+)
+
+func TestRoleHierarchy(t *testing.T) {
+	h := newRoleHierarchy()
+
+	// Current role inheritance tree:
+	//   admin -> editor -> viewer
+	//   auditor -> viewer
+	h.addEdge("admin", "editor")
+	h.addEdge("editor", "viewer")
+	h.addEdge("auditor", "viewer")
+
+	if res := h.ancestors("admin"); !util.ArrayEquals(res, []string{"editor", "viewer"}) {
+		t.Errorf("ancestors of admin: %s, supposed to be [editor viewer]", res)
+	}
+	if res := h.descendants("viewer"); !util.ArrayEquals(res, []string{"auditor", "editor", "admin"}) {
+		t.Errorf("descendants of viewer: %s, supposed to be [auditor editor admin]", res)
+	}
+
+	h.removeEdge("editor", "viewer")
+	if res := h.descendants("viewer"); !util.ArrayEquals(res, []string{"auditor"}) {
+		t.Errorf("descendants of viewer: %s, supposed to be [auditor]", res)
+	}
+	if h.hasEdge("editor", "viewer") {
+		t.Error("editor < viewer should have been removed")
+	}
+
+	// Traversal terminates even if the graph contains a cycle.
+	h.addEdge("viewer", "admin")
+	h.addEdge("editor", "viewer")
+	if res := h.ancestors("admin"); !util.ArrayEquals(res, []string{"editor", "viewer"}) {
+		t.Errorf("ancestors of admin: %s, supposed to be [editor viewer]", res)
+	}
+}
+
+func TestRoleLinks(t *testing.T) {
+	rm := &RoleManager{
+		roles:     map[string]bool{"admin": true, "editor": true, "viewer": true},
+		hierarchy: newRoleHierarchy(),
+	}
+
+	if err := rm.AddLink("admin", "editor"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.AddLink("editor", "viewer"); err != nil {
+		t.Fatal(err)
+	}
+
+	testRole(t, rm, "admin", "viewer", true)
+	testRole(t, rm, "viewer", "admin", false)
+	testRole(t, rm, "editor", "editor", true)
+
+	if err := rm.DeleteLink("editor", "viewer"); err != nil {
+		t.Fatal(err)
+	}
+	testRole(t, rm, "admin", "viewer", false)
+
+	if err := rm.DeleteLink("editor", "viewer"); err == nil {
+		t.Error("deleting a missing link should fail")
+	}
+}
+
+func TestMaxHierarchyLevel(t *testing.T) {
+	rm := &RoleManager{
+		roles:     map[string]bool{"admin": true, "editor": true, "viewer": true, "guest": true},
+		hierarchy: newRoleHierarchy(),
+	}
+	_ = rm.AddLink("admin", "editor")
+	_ = rm.AddLink("editor", "viewer")
+	_ = rm.AddLink("viewer", "guest")
+
+	testRole(t, rm, "admin", "guest", true)
+
+	if err := rm.SetMaxHierarchyLevel(2); err != nil {
+		t.Fatal(err)
+	}
+	testRole(t, rm, "admin", "viewer", true)
+	testRole(t, rm, "admin", "guest", false)
+	testRole(t, rm, "editor", "guest", true)
+
+	if res := rm.hierarchy.descendantsWithin("guest", 2); !util.ArrayEquals(res, []string{"viewer", "editor"}) {
+		t.Errorf("descendants of guest: %s, supposed to be [viewer editor]", res)
+	}
+	if err := rm.SetMaxHierarchyLevel(0); err == nil {
+		t.Error("a max hierarchy level of 0 should be rejected")
+	}
+}
+
+func TestRoleHierarchyCycle(t *testing.T) {
+	rm := &RoleManager{
+		roles:     map[string]bool{"admin": true, "editor": true, "viewer": true},
+		hierarchy: newRoleHierarchy(),
+	}
+	_ = rm.AddLink("admin", "editor")
+	_ = rm.AddLink("editor", "viewer")
+
+	err := rm.AddLink("viewer", "admin")
+	cycleErr, ok := err.(*CycleError)
+	if !ok {
+		t.Fatalf("viewer < admin: %v, supposed to be a *CycleError", err)
+	}
+	if !util.ArrayEquals(cycleErr.Cycle, []string{"viewer", "admin", "editor", "viewer"}) {
+		t.Errorf("cycle: %s, supposed to be [viewer admin editor viewer]", cycleErr.Cycle)
+	}
+	if _, ok := rm.AddLink("admin", "admin").(*CycleError); !ok {
+		t.Error("admin < admin should be rejected as a cycle")
+	}
+	testRole(t, rm, "viewer", "admin", false)
+
+	// Importing drops the edge closing the cycle.
+	h := newRoleHierarchyFromEdges([]Edge{
+		{Role: "admin", Parent: "editor"},
+		{Role: "editor", Parent: "viewer"},
+		{Role: "viewer", Parent: "admin"},
+	}, nil)
+	if h.hasEdge("viewer", "admin") {
+		t.Error("viewer < admin should have been dropped on import")
+	}
+	if res := h.ancestors("admin"); !util.ArrayEquals(res, []string{"editor", "viewer"}) {
+		t.Errorf("ancestors of admin: %s, supposed to be [editor viewer]", res)
+	}
+}
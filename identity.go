@@ -0,0 +1,192 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/auth0/go-auth0/management"
+)
+
+// IdentityResolver returns the name identifying a user in policies, or ""
+// if the user cannot be identified.
+type IdentityResolver func(user *management.User) string
+
+// EmailIdentity identifies users by email, the default.
+func EmailIdentity(user *management.User) string {
+	return user.GetEmail()
+}
+
+// UserIDIdentity identifies users by Auth0 user ID, e.g. "auth0|123".
+func UserIDIdentity(user *management.User) string {
+	return user.GetID()
+}
+
+// UsernameIdentity identifies users by username.
+func UsernameIdentity(user *management.User) string {
+	return user.GetUsername()
+}
+
+// NicknameIdentity identifies users by nickname.
+func NicknameIdentity(user *management.User) string {
+	return user.GetNickname()
+}
+
+var identityResolvers = map[string]IdentityResolver{
+	"email":    EmailIdentity,
+	"user_id":  UserIDIdentity,
+	"username": UsernameIdentity,
+	"nickname": NicknameIdentity,
+}
+
+// SetUserIdentity sets the user attribute used as user name in policies:
+// "email" (the default), "user_id", "username" or "nickname", for tenants
+// whose users have no unique email, e.g. with SMS or enterprise
+// connections. The user mapping is rebuilt with the new names; call Refresh
+// if the attribute was left out by SetUserFields.
+func (rm *RoleManager) SetUserIdentity(attribute string) error {
+	resolver, ok := identityResolvers[attribute]
+	if !ok {
+		return fmt.Errorf("error: unknown user identity %s", attribute)
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.identityAttribute = attribute
+	rm.identityResolver = resolver
+	rm.indexUsers()
+	rm.indexRoles()
+	return nil
+}
+
+// SetIdentityResolver sets a custom function naming users in policies,
+// e.g. from their app metadata. Users missing from the mapping cannot be
+// searched by a custom name, so they are only found once the mapping is
+// reloaded, see Refresh and WithMappingTTL. Include the fields read by
+// resolver with SetUserFields, if restricted. The user mapping is rebuilt
+// with the new names.
+func (rm *RoleManager) SetIdentityResolver(resolver IdentityResolver) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.identityAttribute = ""
+	rm.identityResolver = resolver
+	rm.indexUsers()
+	rm.indexRoles()
+}
+
+// identity returns the name of user in policies. rm.mu must be held.
+func (rm *RoleManager) identity(user *management.User) string {
+	if rm.identityResolver == nil {
+		return EmailIdentity(user)
+	}
+	return rm.identityResolver(user)
+}
+
+// searchAttribute returns the user attribute to search for the users
+// missing from the mapping, or "" if the identity is custom. rm.mu must be
+// held.
+func (rm *RoleManager) searchAttribute() string {
+	if rm.identityResolver == nil {
+		return "email"
+	}
+	return rm.identityAttribute
+}
+
+// userName returns the name of a user returned by Auth0, from the mapping
+// if known, as listings of role members only return partial profiles.
+// rm.mu must be held.
+func (rm *RoleManager) userName(user *management.User) string {
+	if name, ok := rm.idToNameMap[user.GetID()]; ok {
+		return name
+	}
+	return rm.identity(user)
+}
+
+// indexUsers rebuilds the (ID, name) mapping of the users from the profiles
+// loaded from Auth0, applying the identity resolver. Users without a name
+// are left out. rm.mu must be held for writing, and indexRoles called
+// afterwards, as role names take precedence.
+func (rm *RoleManager) indexUsers() {
+	for id := range rm.profiles {
+		if name, ok := rm.idToNameMap[id]; ok {
+			if rm.nameToIDMap[name] == id {
+				delete(rm.nameToIDMap, name)
+			}
+			delete(rm.idToNameMap, id)
+		}
+	}
+
+	ids := make([]string, 0, len(rm.profiles))
+	for id := range rm.profiles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		name := rm.identity(rm.profiles[id])
+		if name == "" {
+			rm.logf(LevelWarn, "User %s has no name, skipping it", id)
+			continue
+		}
+		rm.nameToIDMap[name] = id
+		rm.idToNameMap[id] = name
+		rm.logf(LevelDebug, "%s -> %s", id, rm.pii(name))
+	}
+}
+
+// findUsers searches Auth0 for the users whose attribute is name, see
+// searchAttribute, and matching query if not empty, see userQuery.
+func (rm *RoleManager) findUsers(ctx context.Context, name string, attribute string, query string, fields []string) ([]*management.User, error) {
+	var users []*management.User
+	err := rm.call(ctx, func() error {
+		switch {
+		case query != "":
+			q := attribute + ":" + quoteValue(name) + " AND " + query
+			list, err := rm.api.ListUsers(ctx, ListOptions{Fields: fields, Query: q})
+			if err != nil {
+				return err
+			}
+			users = list.Users
+			return nil
+		case attribute == "email":
+			var err error
+			users, err = rm.api.ListUsersByEmail(ctx, name, fields)
+			return err
+		case attribute == "user_id":
+			user, err := rm.api.ReadUser(ctx, name, fields)
+			if mErr, ok := err.(management.Error); ok && mErr.Status() == http.StatusNotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			users = []*management.User{user}
+			return nil
+		default:
+			list, err := rm.api.ListUsers(ctx, ListOptions{Fields: fields, Query: attribute + ":" + quoteValue(name)})
+			if err != nil {
+				return err
+			}
+			users = list.Users
+			return nil
+		}
+	})
+	return users, err
+}
@@ -0,0 +1,88 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/auth0/go-auth0"
+	"github.com/auth0/go-auth0/management"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestUserIdentity(t *testing.T) {
+	fake := auth0test.New()
+	fake.AddUser(&management.User{
+		ID: auth0.String("auth0|alice"), Email: auth0.String("alice@example.com"),
+		Username: auth0.String("alice"), Nickname: auth0.String("Al"),
+	})
+	fake.AddUser(&management.User{ID: auth0.String("sms|bob"), Nickname: auth0.String("Bob")})
+	addRole(fake, "rol_admin", "admin")
+	assignRoles(t, fake, "auth0|alice", "rol_admin")
+	rm := newFakeRoleManager(t, fake, WithUserIdentity("username"))
+
+	// carol is created after the load.
+	fake.AddUser(&management.User{ID: auth0.String("auth0|carol"), Username: auth0.String("carol")})
+	assignRoles(t, fake, "auth0|carol", "rol_admin")
+
+	// bob has no username, so he is left out.
+	if _, ok := rm.idToNameMap["sms|bob"]; ok || rm.nameToIDMap["alice"] != "auth0|alice" {
+		t.Errorf("mapping: %v, supposed to map alice to auth0|alice and to leave bob out", rm.nameToIDMap)
+	}
+	testPrintRoles(t, rm, "alice", []string{"admin"})
+
+	// Users missing from the mapping are searched by username.
+	testPrintRoles(t, rm, "carol", []string{"admin"})
+	if rm.nameToIDMap["carol"] != "auth0|carol" {
+		t.Error("carol should have been added to the mapping")
+	}
+
+	rm.SetIdentityResolver(func(user *management.User) string {
+		return strings.ToLower(user.GetNickname())
+	})
+	if rm.nameToIDMap["al"] != "auth0|alice" || rm.nameToIDMap["bob"] != "sms|bob" {
+		t.Errorf("mapping: %v, supposed to be rebuilt from the nicknames", rm.nameToIDMap)
+	}
+	if _, ok := rm.nameToIDMap["alice"]; ok {
+		t.Error("alice should have been renamed")
+	}
+
+	if err := rm.SetUserIdentity("phone_number"); err == nil {
+		t.Error("an unknown user identity should be rejected")
+	}
+}
+
+func TestUserQueryEscaping(t *testing.T) {
+	name := `we\ird*name? OR username:"alice"`
+	fake := auth0test.New()
+	fake.AddUser(&management.User{ID: auth0.String("auth0|alice"), Username: auth0.String("alice")})
+	fake.AddUser(&management.User{ID: auth0.String("auth0|weird"), Username: auth0.String(name)})
+	rm := newFakeRoleManager(t, fake, WithUserIdentity("username"))
+
+	ids, err := rm.findUsers(context.Background(), name, "username", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0].GetID() != "auth0|weird" {
+		t.Errorf("users: %v, supposed to be auth0|weird only", ids)
+	}
+	expected := `username:"we\\ird\*name\? OR username\:\"alice\""`
+	if queries := userQueries(fake)[1:]; len(queries) != 1 || queries[0] != expected {
+		t.Errorf("lookup queries: %q, supposed to be [%q]", queries, expected)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ImportHierarchy reads role -> role links and adds them to the local role
+// hierarchy and its store. The input is either a casbin policy CSV, of
+// which only the "g" rules are used, or a simple edge list with one
+// "role, parent" pair per line.
+//
+// Rules whose names are not both Auth0 roles (e.g. user assignments, which
+// are managed in Auth0), links that would create a cycle and the rules of
+// other grouping policies, e.g. "g2", are skipped and logged.
+func (rm *RoleManager) ImportHierarchy(r io.Reader) error {
+	return rm.ImportHierarchyCtx(context.Background(), r)
+}
+
+// ImportHierarchyCtx is like ImportHierarchy, stopping at the first link
+// not imported yet once ctx is done.
+func (rm *RoleManager) ImportHierarchyCtx(ctx context.Context, r io.Reader) error {
+	edges, skipped, err := parseHierarchy(r)
+	if err != nil {
+		return err
+	}
+	for _, rule := range skipped {
+		rm.logf(LevelWarn, "Skipping %s: not a rule of the g grouping policy", rule)
+	}
+
+	for _, e := range edges {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rm.mu.RLock()
+		exists := rm.hierarchy.hasEdge(e.Role, e.Parent)
+		roles := rm.roles[e.Role] && rm.roles[e.Parent]
+		rm.mu.RUnlock()
+
+		if exists {
+			continue
+		}
+		if !roles {
+			rm.logf(LevelWarn, "Skipping link %s -> %s: not a link between two Auth0 roles", e.Role, e.Parent)
+			continue
+		}
+		err := rm.addRoleLink(e.Role, e.Parent)
+		if _, ok := err.(*CycleError); ok {
+			rm.logf(LevelWarn, "Skipping link %s -> %s: %v", e.Role, e.Parent, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// policyTypeRegexp matches the policy types of casbin policy rules, e.g.
+// "p", "g" or "g2".
+var policyTypeRegexp = regexp.MustCompile(`^[pg][0-9]*$`)
+
+// parseHierarchy reads the edges of a casbin policy CSV or an edge list. It
+// also returns the rules of the other grouping policies, which are not
+// edges, e.g. "line 3: g2, alice, admin".
+func parseHierarchy(r io.Reader) ([]Edge, []string, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	edges := []Edge{}
+	skipped := []string{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+
+		line, _ := reader.FieldPos(0)
+		policyType := policyTypeRegexp.MatchString(record[0])
+		grouping := policyType && strings.HasPrefix(record[0], "g")
+		switch {
+		case grouping && len(record) < 3:
+			return nil, nil, fmt.Errorf("line %d: %s rules need a role and a parent role", line, record[0])
+		case record[0] == "g" && len(record) == 3:
+			edges = append(edges, Edge{Role: record[1], Parent: record[2]})
+		case record[0] == "g":
+			return nil, nil, fmt.Errorf("line %d: g rules with domains are not supported", line)
+		case grouping:
+			skipped = append(skipped, fmt.Sprintf("line %d: %s", line, strings.Join(record, ", ")))
+		case policyType:
+			// Policy rules are not links.
+		case len(record) == 2:
+			edges = append(edges, Edge{Role: record[0], Parent: record[1]})
+		default:
+			return nil, nil, fmt.Errorf("line %d: invalid edge: %q", line, strings.Join(record, ", "))
+		}
+	}
+	return edges, skipped, nil
+}
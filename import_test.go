@@ -0,0 +1,68 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportHierarchy(t *testing.T) {
+	rm := &RoleManager{
+		roles:     map[string]bool{"admin": true, "editor": true, "viewer": true},
+		hierarchy: newRoleHierarchy(),
+	}
+
+	policy := `
+p, viewer, data1, read
+p, editor, data1, write
+# role hierarchy
+g, admin, editor
+g, alice@test.com, admin
+editor, viewer
+g, viewer, admin
+`
+	if err := rm.ImportHierarchy(strings.NewReader(policy)); err != nil {
+		t.Fatal(err)
+	}
+
+	testRole(t, rm, "admin", "viewer", true)
+	testRole(t, rm, "viewer", "admin", false)
+	if rm.hierarchy.hasEdge("alice@test.com", "admin") {
+		t.Error("user assignments should not be imported")
+	}
+
+	err := rm.ImportHierarchy(strings.NewReader("g, admin, editor, domain1\n"))
+	if err == nil {
+		t.Error("g rules with domains should be rejected")
+	}
+	for _, policy := range []string{"g, admin\n", "g2, admin\n", "g\n", "editor, viewer, admin\n"} {
+		if _, _, err := parseHierarchy(strings.NewReader(policy)); err == nil {
+			t.Errorf("%q should be rejected, not read as an edge", policy)
+		}
+	}
+
+	// Policy rules are not edges, and other grouping rules are reported.
+	edges, skipped, err := parseHierarchy(strings.NewReader("p, admin\np2, admin, data1\ng2, alice@test.com, admin\neditor, viewer\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edges) != 1 || edges[0] != (Edge{Role: "editor", Parent: "viewer"}) {
+		t.Errorf("edges: %v, supposed to be [{editor viewer}]", edges)
+	}
+	if len(skipped) != 1 || skipped[0] != "line 3: g2, alice@test.com, admin" {
+		t.Errorf("skipped: %q, supposed to be the g2 rule", skipped)
+	}
+}
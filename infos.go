@@ -0,0 +1,237 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import "context"
+
+// Sources of the roles and users returned by GetRoleInfos and GetUserInfos.
+const (
+	// SourceAuth0 is a role assigned to the user in Auth0.
+	SourceAuth0 = "auth0"
+	// SourceHierarchy is a role or user obtained through the local role hierarchy.
+	SourceHierarchy = "hierarchy"
+	// SourceSynthetic is a synthetic role computed from the user profile.
+	SourceSynthetic = "synthetic"
+	// SourcePattern is a role or user obtained through a name matching the
+	// pattern given, see AddMatchingFunc.
+	SourcePattern = "pattern"
+)
+
+// Origin is a path through which a role reaches a user.
+type Origin struct {
+	Source string `json:"source"`
+	// Via is the role the origin goes through for SourceHierarchy, or the
+	// name matching the pattern for SourcePattern.
+	Via string `json:"via,omitempty"`
+}
+
+// RoleInfo describes a role returned by GetRoleInfos.
+type RoleInfo struct {
+	// ID is the Auth0 ID of the role, empty for synthetic roles.
+	ID string `json:"id,omitempty"`
+	// Name is the name of the role used in policies.
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Source is the first of the origins.
+	Source string `json:"source"`
+	Domain string `json:"domain,omitempty"`
+	// Origins are all the paths through which the role reaches the user.
+	Origins []Origin `json:"origins"`
+}
+
+// UserInfo describes a user returned by GetUserInfos.
+type UserInfo struct {
+	// ID is the Auth0 ID of the user.
+	ID string `json:"id"`
+	// Name is the name of the user used in policies.
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	// Source is the first of the origins.
+	Source string `json:"source"`
+	Domain string `json:"domain,omitempty"`
+	// Origins are all the paths through which the user obtains the role.
+	Origins []Origin `json:"origins"`
+}
+
+// GetRoleInfos gets the roles that a subject inherits, with their details.
+// Unlike GetRoles, the roles inherited through the local role hierarchy are
+// included. A role reaching the user through several paths is returned once,
+// with all of them as origins. With a matching function, see
+// AddMatchingFunc, name can be a pattern of users, and the roles of the
+// matching users have them as origins.
+// domain is an Auth0 Organization, see EnableOrganizations.
+func (rm *RoleManager) GetRoleInfos(name string, domain ...string) ([]RoleInfo, error) {
+	return rm.GetRoleInfosCtx(context.Background(), name, domain...)
+}
+
+// GetRoleInfosCtx is like GetRoleInfos, with ctx bounding the Management API calls.
+func (rm *RoleManager) GetRoleInfosCtx(ctx context.Context, name string, domain ...string) ([]RoleInfo, error) {
+	orgID, err := rm.organizationID(ctx, domain...)
+	if err != nil {
+		return nil, err
+	}
+
+	rm.refreshIfStale()
+
+	users := rm.matchingUsers(name)
+	if len(users) == 0 {
+		users = []string{name}
+	}
+	res := []RoleInfo{}
+	index := map[string]int{}
+	for _, user := range users {
+		roles, err := rm.getRoleInfos(ctx, user, orgID)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range roles {
+			if user != name {
+				role.Source = SourcePattern
+				role.Origins = []Origin{{Source: SourcePattern, Via: user}}
+			}
+			if i, ok := index[role.Name]; ok {
+				res[i].Origins = append(res[i].Origins, role.Origins...)
+				continue
+			}
+			index[role.Name] = len(res)
+			res = append(res, role)
+		}
+	}
+	if len(domain) > 0 {
+		for i := range res {
+			res[i].Domain = domain[0]
+		}
+	}
+	return res, nil
+}
+
+// getRoleInfos gets the roles of a user, in an organization if orgID is not
+// empty, followed by the roles inherited through the local role hierarchy and
+// its synthetic roles.
+func (rm *RoleManager) getRoleInfos(ctx context.Context, name string, orgID string) ([]RoleInfo, error) {
+	res, err := rm.getAuth0UserRoles(ctx, name, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[string]int{}
+	for i, role := range res {
+		index[role.Name] = i
+	}
+	add := func(info RoleInfo, origin Origin) {
+		if i, ok := index[info.Name]; ok {
+			res[i].Origins = append(res[i].Origins, origin)
+			return
+		}
+		info.Source = origin.Source
+		info.Origins = []Origin{origin}
+		index[info.Name] = len(res)
+		res = append(res, info)
+	}
+
+	synthetic := rm.syntheticRoles(name)
+
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	for _, role := range roleInfoNames(res) {
+		for _, ancestor := range rm.hierarchy.ancestorsWithin(role, rm.hierarchyLevels()) {
+			add(rm.roleInfo(ancestor), Origin{Source: SourceHierarchy, Via: role})
+		}
+	}
+	for _, role := range synthetic {
+		if !rm.roleExcluded(role) {
+			add(RoleInfo{Name: role}, Origin{Source: SourceSynthetic})
+		}
+	}
+	return res, nil
+}
+
+// GetUserInfos gets the users that inherit a role, with their details. A
+// user obtaining the role through several paths is returned once, with all
+// of them as origins. With a matching function, see AddMatchingFunc, name
+// can be a pattern of roles, and the users of the matching roles have them
+// as origins.
+// domain is an Auth0 Organization, see EnableOrganizations.
+func (rm *RoleManager) GetUserInfos(name string, domain ...string) ([]UserInfo, error) {
+	return rm.GetUserInfosCtx(context.Background(), name, domain...)
+}
+
+// GetUserInfosCtx is like GetUserInfos, with ctx bounding the Management API calls.
+func (rm *RoleManager) GetUserInfosCtx(ctx context.Context, name string, domain ...string) ([]UserInfo, error) {
+	orgID, err := rm.organizationID(ctx, domain...)
+	if err != nil {
+		return nil, err
+	}
+
+	rm.refreshIfStale()
+
+	roles := rm.matchingRoles(name)
+	if len(roles) == 0 {
+		roles = []string{name}
+	}
+	res := []UserInfo{}
+	index := map[string]int{}
+	for _, role := range roles {
+		users, err := rm.getImplicitUserInfos(ctx, role, orgID)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			if role != name {
+				user.Source = SourcePattern
+				user.Origins = []Origin{{Source: SourcePattern, Via: role}}
+			}
+			if i, ok := index[user.Name]; ok {
+				res[i].Origins = append(res[i].Origins, user.Origins...)
+				continue
+			}
+			index[user.Name] = len(res)
+			res = append(res, user)
+		}
+	}
+	if len(domain) > 0 {
+		for i := range res {
+			res[i].Domain = domain[0]
+		}
+	}
+	return res, nil
+}
+
+// roleInfo describes a role known from the role mapping. rm.mu must be held.
+func (rm *RoleManager) roleInfo(name string) RoleInfo {
+	info := RoleInfo{Name: name}
+	if role, ok := rm.auth0Roles[rm.nameToIDMap[name]]; ok {
+		info.ID = role.GetID()
+		info.Description = role.GetDescription()
+	}
+	return info
+}
+
+func roleInfoNames(roles []RoleInfo) []string {
+	res := make([]string, 0, len(roles))
+	for _, role := range roles {
+		res = append(res, role.Name)
+	}
+	return res
+}
+
+func userInfoNames(users []UserInfo) []string {
+	res := make([]string, 0, len(users))
+	for _, user := range users {
+		res = append(res, user.Name)
+	}
+	return res
+}
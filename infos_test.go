@@ -0,0 +1,142 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestRoleInfos(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	addUser(fake, "auth0|bob", "bob@example.com")
+	addRole(fake, "rol_admin", "admin")
+	addRole(fake, "rol_editor", "editor")
+	addRole(fake, "rol_viewer", "viewer")
+	assignRoles(t, fake, "auth0|alice", "rol_admin", "rol_editor")
+	assignRoles(t, fake, "auth0|bob", "rol_viewer")
+	rm := newFakeRoleManager(t, fake)
+	if err := rm.AddLink("admin", "editor"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.AddLink("editor", "viewer"); err != nil {
+		t.Fatal(err)
+	}
+	rm.SetSyntheticRoleProvider(func(user ProfileView) []string {
+		if user.Email == "alice@example.com" {
+			return []string{"viewer", "beta-tester"}
+		}
+		return nil
+	})
+
+	roles, err := rm.GetRoleInfos("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []RoleInfo{
+		{ID: "rol_admin", Name: "admin", Source: SourceAuth0, Origins: []Origin{{Source: SourceAuth0}}},
+		{ID: "rol_editor", Name: "editor", Source: SourceAuth0, Origins: []Origin{
+			{Source: SourceAuth0},
+			{Source: SourceHierarchy, Via: "admin"},
+		}},
+		{ID: "rol_viewer", Name: "viewer", Source: SourceHierarchy, Origins: []Origin{
+			{Source: SourceHierarchy, Via: "admin"},
+			{Source: SourceHierarchy, Via: "editor"},
+			{Source: SourceSynthetic},
+		}},
+		{Name: "beta-tester", Source: SourceSynthetic, Origins: []Origin{{Source: SourceSynthetic}}},
+	}
+	if !reflect.DeepEqual(roles, expected) {
+		t.Errorf("roles of alice@example.com: %+v, supposed to be %+v", roles, expected)
+	}
+
+	// The roles of the users matching a pattern have them as origins.
+	rm.SetSyntheticRoleProvider(nil)
+	rm.AddMatchingFunc("glob", globMatch)
+	roles, err = rm.GetRoleInfos("*@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewer := roles[len(roles)-1]
+	origins := []Origin{
+		{Source: SourcePattern, Via: "alice@example.com"},
+		{Source: SourcePattern, Via: "bob@example.com"},
+	}
+	if len(roles) != 3 || viewer.Name != "viewer" || viewer.Source != SourcePattern || !reflect.DeepEqual(viewer.Origins, origins) {
+		t.Errorf("roles of *@example.com: %+v, supposed to end with viewer from %+v", roles, origins)
+	}
+}
+
+func TestUserInfos(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	addUser(fake, "auth0|bob", "bob@example.com")
+	addUser(fake, "auth0|carol", "carol@example.com")
+	addRole(fake, "rol_admin", "admin")
+	addRole(fake, "rol_editor", "editor")
+	addRole(fake, "rol_viewer", "viewer")
+	assignRoles(t, fake, "auth0|alice", "rol_admin")
+	assignRoles(t, fake, "auth0|bob", "rol_editor", "rol_viewer")
+	assignRoles(t, fake, "auth0|carol", "rol_viewer")
+	rm := newFakeRoleManager(t, fake)
+	if err := rm.AddLink("admin", "editor"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.AddLink("editor", "viewer"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	users, err := rm.GetUserInfosCtx(ctx, "viewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []UserInfo{
+		{ID: "auth0|bob", Name: "bob@example.com", Email: "bob@example.com", Source: SourceAuth0, Origins: []Origin{
+			{Source: SourceAuth0},
+			{Source: SourceHierarchy, Via: "editor"},
+		}},
+		{ID: "auth0|carol", Name: "carol@example.com", Email: "carol@example.com", Source: SourceAuth0, Origins: []Origin{{Source: SourceAuth0}}},
+		{ID: "auth0|alice", Name: "alice@example.com", Email: "alice@example.com", Source: SourceHierarchy, Origins: []Origin{{Source: SourceHierarchy, Via: "admin"}}},
+	}
+	if !reflect.DeepEqual(users, expected) {
+		t.Errorf("users of viewer: %+v, supposed to be %+v", users, expected)
+	}
+
+	// The users of the roles matching a pattern have them as origins.
+	rm.AddMatchingFunc("glob", globMatch)
+	users, err = rm.GetUserInfosCtx(ctx, "*r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob := users[0]
+	origins := []Origin{
+		{Source: SourcePattern, Via: "editor"},
+		{Source: SourcePattern, Via: "viewer"},
+	}
+	if len(users) != 3 || bob.Name != "bob@example.com" || bob.Source != SourcePattern || !reflect.DeepEqual(bob.Origins, origins) {
+		t.Errorf("users of *r: %+v, supposed to have bob@example.com from %+v", users, origins)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := rm.GetUserInfosCtx(ctx, "viewer"); err == nil {
+		t.Error("GetUserInfosCtx should fail with a canceled context")
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package api holds the types shared by the role manager and the auth0test
+// package, so that the tests of the role manager can use auth0test.
+package api
+
+// ListOptions are the options of the list calls of a ManagementAPI.
+type ListOptions struct {
+	// Page is the index of the page, starting at 0, of PerPage items. The
+	// first page of the default size of the API is listed if PerPage is 0.
+	Page    int
+	PerPage int
+	// From is the checkpoint of checkpoint pagination, the Next of the
+	// previous page, empty for the first page, and Take the number of items
+	// of its pages. Checkpoint pagination is used if Take is not 0, and
+	// lists past the 1000 items reachable with Page.
+	From string
+	Take int
+	// Fields restricts the user fields returned, all if nil.
+	Fields []string
+	// Query is a user search query in the Auth0 Lucene syntax.
+	Query string
+	// NameFilter filters the roles whose name contains it.
+	NameFilter string
+}
@@ -0,0 +1,338 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/auth0/go-auth0/management"
+)
+
+// defaultLoadConcurrency is the number of pages fetched at once when
+// loading the mapping.
+const defaultLoadConcurrency = 4
+
+// maxPaged is the number of items of a list that can be paged through in
+// Auth0.
+const maxPaged = 1000
+
+// errPageLimit is returned by fetchPages for lists of more than maxPaged
+// items.
+var errPageLimit = fmt.Errorf("error: only the first %d items can be paged through", maxPaged)
+
+// LoadProgress reports the progress of the loading of the (ID, name)
+// mapping, after every page fetched from Auth0, see WithLoadProgress.
+type LoadProgress struct {
+	// Kind is "users" or "roles".
+	Kind string
+	// Page is the index of the page fetched.
+	Page int
+	// PagesDone and Pages are the numbers of pages fetched and to fetch.
+	PagesDone int
+	Pages     int
+	// Total is the number of users or roles in Auth0.
+	Total int
+}
+
+// LoadError is returned when pages of users or roles could not be fetched
+// while loading the mapping. The current mapping is then kept, and the
+// pages already fetched are reused by the next Load, which fetches only
+// the missing ones.
+type LoadError struct {
+	// Kind is "users" or "roles".
+	Kind string
+	// MissingPages are the indexes of the pages not fetched.
+	MissingPages []int
+	Err          error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("error: loading %s failed, %d pages missing: %v", e.Kind, len(e.MissingPages), e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// loadState is the state of a load of the mapping, kept after a failure to
+// resume it.
+type loadState struct {
+	perPage int
+	fields  string
+	query   string
+
+	mu         sync.Mutex
+	users      pageState
+	roles      pageState
+	profiles   map[string]*management.User
+	auth0Roles map[string]*management.Role
+}
+
+// pageState tracks the pages of a list fetched.
+type pageState struct {
+	pages int
+	total int
+	done  map[int]bool
+}
+
+// missing returns the pages not fetched yet. The first page is missing
+// until the number of pages is known from it.
+func (p *pageState) missing() []int {
+	if p.pages == 0 {
+		return []int{0}
+	}
+	res := []int{}
+	for i := 0; i < p.pages; i++ {
+		if !p.done[i] {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+// newLoadState returns the state of a load of the users matching fields
+// and query, resuming s if it was for the same ones.
+func (rm *RoleManager) newLoadState(s *loadState, fields []string, query string) *loadState {
+	key := strings.Join(fields, ",")
+	if fields == nil {
+		key = "*"
+	}
+	if s != nil && s.perPage == rm.pageSize && s.fields == key && s.query == query {
+		return s
+	}
+	return &loadState{
+		perPage:    rm.pageSize,
+		fields:     key,
+		query:      query,
+		users:      pageState{done: map[int]bool{}},
+		roles:      pageState{done: map[int]bool{}},
+		profiles:   map[string]*management.User{},
+		auth0Roles: map[string]*management.Role{},
+	}
+}
+
+// fetchPages fetches the pages of a list missing from p, the first one
+// alone to learn the number of pages and the others with up to the load
+// concurrency at once. add is called with each page, s.mu held. No new page
+// is fetched after a failure, nor after the first page of a list of more
+// than maxPaged items, for which errPageLimit is returned.
+func fetchPages[T any](ctx context.Context, rm *RoleManager, s *loadState, p *pageState, kind string,
+	f func(context.Context, ListOptions) (T, error), opts ListOptions, list func(T) management.List, add func(T)) error {
+	opts.PerPage = s.perPage
+
+	fetch := func(page int) error {
+		opts := opts
+		opts.Page = page
+		var res T
+		err := rm.call(ctx, func() error {
+			var err error
+			res, err = f(ctx, opts)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if page == 0 {
+			p.total = list(res).Total
+			p.pages = (p.total + s.perPage - 1) / s.perPage
+			if p.pages < 1 {
+				p.pages = 1
+			}
+		}
+		add(res)
+		p.done[page] = true
+		if rm.loadProgress != nil {
+			rm.loadProgress(LoadProgress{Kind: kind, Page: page, PagesDone: len(p.done), Pages: p.pages, Total: p.total})
+		}
+		return nil
+	}
+	loadError := func(err error) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return &LoadError{Kind: kind, MissingPages: p.missing(), Err: err}
+	}
+
+	if !p.done[0] {
+		if err := fetch(0); err != nil {
+			return loadError(err)
+		}
+	}
+
+	pages := p.missing()
+	if p.total > maxPaged && len(pages) > 0 {
+		return loadError(errPageLimit)
+	}
+	workers := rm.loadConcurrency
+	if workers > len(pages) {
+		workers = len(pages)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		failed   atomic.Bool
+		errOnce  sync.Once
+		firstErr error
+	)
+	work := make(chan int)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for page := range work {
+				if err := fetch(page); err != nil {
+					errOnce.Do(func() { firstErr = err })
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+feed:
+	for _, page := range pages {
+		if failed.Load() {
+			break
+		}
+		select {
+		case work <- page:
+		case <-ctx.Done():
+			errOnce.Do(func() { firstErr = ctx.Err() })
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if firstErr != nil {
+		return loadError(firstErr)
+	}
+	return nil
+}
+
+// loadMapping loads the (ID, name) mapping of users and roles from Auth0,
+// replacing the current one. If it fails, the pages fetched are kept for
+// the next load, see LoadError. Every load is counted in Stats.
+func (rm *RoleManager) loadMapping(ctx context.Context) (err error) {
+	rm.loadMu.Lock()
+	defer rm.loadMu.Unlock()
+	defer func() {
+		rm.stats.mappingLoads.Add(1)
+		if err != nil {
+			rm.stats.mappingLoadErrors.Add(1)
+		}
+	}()
+
+	rm.mu.RLock()
+	fields := rm.userFields()
+	query := rm.userQuery()
+	rm.mu.RUnlock()
+
+	s := rm.newLoadState(rm.pendingLoad, fields, query)
+	if s == rm.pendingLoad {
+		rm.logf(LevelInfo, "Resuming the load of the mapping")
+	}
+	rm.pendingLoad = s
+
+	rm.logf(LevelInfo, "Loading (ID, name) mapping for users:")
+	err = fetchPages(ctx, rm, s, &s.users, "users", rm.api.ListUsers, ListOptions{Fields: fields, Query: query},
+		func(l *management.UserList) management.List { return l.List },
+		func(l *management.UserList) {
+			for _, user := range l.Users {
+				s.profiles[user.GetID()] = user
+			}
+		})
+	if errors.Is(err, errPageLimit) {
+		err = rm.exportUsers(ctx, s, fields, query)
+	}
+	if err != nil {
+		rm.logf(LevelError, "Error loading users: '%v'", err)
+		return err
+	}
+
+	rm.logf(LevelInfo, "Loading (ID, name) mapping for roles:")
+	err = fetchPages(ctx, rm, s, &s.roles, "roles", rm.api.ListRoles, ListOptions{},
+		func(l *management.RoleList) management.List { return l.List },
+		func(l *management.RoleList) {
+			for _, role := range l.Roles {
+				s.auth0Roles[role.GetID()] = role
+				rm.logf(LevelDebug, "%s -> %s", role.GetID(), role.GetName())
+			}
+		})
+	if err != nil {
+		rm.logf(LevelError, "Error loading roles: '%v'", err)
+		return err
+	}
+	rm.pendingLoad = nil
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.nameToIDMap = map[string]string{}
+	rm.idToNameMap = map[string]string{}
+	rm.profiles = s.profiles
+	rm.auth0Roles = s.auth0Roles
+	rm.orgIDs = map[string]string{}
+	rm.indexUsers()
+	rm.indexRoles()
+	if rm.misses != nil {
+		rm.misses.reset()
+	}
+	rm.loadedAt = rm.now()
+	return nil
+}
+
+// exportUsers fetches the users of a load with a users export job, for more
+// users than can be paged through. Export jobs cannot search users, so
+// loads restricted by a query fail.
+func (rm *RoleManager) exportUsers(ctx context.Context, s *loadState, fields []string, query string) error {
+	if query != "" {
+		return fmt.Errorf("error: %d users match the user query, more than the %d that can be searched, see WithUserQuery", s.users.total, maxPaged)
+	}
+
+	rm.logf(LevelInfo, "Exporting %d users with a users export job", s.users.total)
+	var users []*management.User
+	err := rm.call(ctx, func() error {
+		var err error
+		users, err = rm.api.ExportUsers(ctx, fields)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range users {
+		s.profiles[user.GetID()] = user
+	}
+	for _, page := range s.users.missing() {
+		s.users.done[page] = true
+	}
+	if rm.loadProgress != nil {
+		rm.loadProgress(LoadProgress{Kind: "users", Page: s.users.pages - 1, PagesDone: s.users.pages, Pages: s.users.pages, Total: s.users.total})
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestLoadPages(t *testing.T) {
+	const users = 9
+	var (
+		mu       sync.Mutex
+		requests = map[int]int{}
+		fail     atomic.Bool
+		inFlight atomic.Int32
+		peak     atomic.Int32
+	)
+	fail.Store(true)
+	fake := auth0test.New()
+	for i := 0; i < users; i++ {
+		addUser(fake, fmt.Sprintf("auth0|%d", i), fmt.Sprintf("user%d@example.com", i))
+	}
+	addRole(fake, "rol_admin", "admin")
+	fake.Intercept(func(c auth0test.Call) error {
+		if c.Method != "ListUsers" {
+			return nil
+		}
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		requests[c.Options.Page]++
+		mu.Unlock()
+		if c.Options.Page == 3 && fail.Load() {
+			return &auth0test.Error{StatusCode: 500, Message: "failed"}
+		}
+		return nil
+	})
+
+	progress := []LoadProgress{}
+	rm, err := newRoleManager("", "", "",
+		WithManagementAPI(fake),
+		WithPageSize(2),
+		WithLoadConcurrency(2),
+		WithLoadProgress(func(p LoadProgress) {
+			progress = append(progress, p)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = rm.Load()
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || loadErr.Kind != "users" || len(loadErr.MissingPages) == 0 || loadErr.MissingPages[0] != 3 {
+		t.Fatalf("first load: %v, supposed to miss page 3 of users", err)
+	}
+	if len(rm.nameToIDMap) != 0 {
+		t.Errorf("mapping after a failed load: %v, supposed to be empty", rm.nameToIDMap)
+	}
+
+	fail.Store(false)
+	if err := rm.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rm.profiles) != users || rm.nameToIDMap["user8@example.com"] != "auth0|8" || !rm.roles["admin"] {
+		t.Errorf("mapping: %v, supposed to have %d users and admin", rm.nameToIDMap, users)
+	}
+	for page, n := range requests {
+		if page != 3 && n != 1 {
+			t.Errorf("page %d fetched %d times, supposed to be once", page, n)
+		}
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("%d pages fetched at once, supposed to be at most 2", p)
+	}
+
+	last := progress[len(progress)-1]
+	if last.Kind != "roles" || last.PagesDone != 1 || last.Pages != 1 {
+		t.Errorf("last progress: %+v, supposed to be the single page of roles", last)
+	}
+	usersDone := 0
+	for _, p := range progress {
+		if p.Kind == "users" && p.Total == users && p.Pages == 5 {
+			usersDone++
+		}
+	}
+	if usersDone != 5 {
+		t.Errorf("%d progress reports of users, supposed to be 5", usersDone)
+	}
+
+	// A new load starts over.
+	if err := rm.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if requests[0] != 2 {
+		t.Errorf("page 0 fetched %d times, supposed to be twice", requests[0])
+	}
+}
+
+func TestLoadExport(t *testing.T) {
+	// More users than can be paged through.
+	const users = 1001
+	fake := auth0test.New()
+	for i := 0; i < users; i++ {
+		addUser(fake, fmt.Sprintf("auth0|%d", i), fmt.Sprintf("user%d@example.com", i))
+	}
+	rm := newFakeRoleManager(t, fake)
+	if len(rm.profiles) != users || rm.nameToIDMap["user1000@example.com"] != "auth0|1000" {
+		t.Errorf("%d users loaded, supposed to be %d", len(rm.profiles), users)
+	}
+	if fake.CallCount("ListUsers") != 1 || fake.CallCount("ExportUsers") != 1 {
+		t.Errorf("calls: %d pages and %d exports, supposed to be the first page and one export",
+			fake.CallCount("ListUsers"), fake.CallCount("ExportUsers"))
+	}
+
+	// Export jobs cannot search users.
+	rm.SetUserQuery("email:user*")
+	if err := rm.Load(); err == nil || !strings.Contains(err.Error(), "user query") {
+		t.Errorf("load: %v, supposed to fail for the user query", err)
+	}
+}
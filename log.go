@@ -0,0 +1,125 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	stdlog "log"
+	"strings"
+
+	"github.com/casbin/casbin/v2/log"
+)
+
+// Level is the severity of a log message.
+type Level int
+
+// Levels of the log messages, by increasing severity.
+const (
+	// LevelDebug is for the details of the loads, e.g. every user mapped.
+	LevelDebug Level = iota
+	// LevelInfo is for the progress of the loads and lookups.
+	LevelInfo
+	// LevelWarn is for ignored data and calls.
+	LevelWarn
+	// LevelError is for failed loads and refreshes.
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// Logger receives the log messages of the role manager, see
+// WithLeveledLogger.
+type Logger interface {
+	Logf(level Level, format string, v ...interface{})
+}
+
+// NewStdLogger returns a Logger printing the messages at or above minLevel
+// with the standard log package, prefixed by their level.
+func NewStdLogger(minLevel Level) Logger {
+	return stdLogger{minLevel: minLevel}
+}
+
+type stdLogger struct {
+	minLevel Level
+}
+
+func (l stdLogger) Logf(level Level, format string, v ...interface{}) {
+	if level >= l.minLevel {
+		stdlog.Printf("[%s] "+format, append([]interface{}{level}, v...)...)
+	}
+}
+
+// logf logs a message to the leveled logger if set, and otherwise to the
+// casbin logger.
+func (rm *RoleManager) logf(level Level, format string, v ...interface{}) {
+	logger, leveledLogger := rm.loggers()
+	if leveledLogger != nil {
+		leveledLogger.Logf(level, format, v...)
+		return
+	}
+	logPrintf(logger, format, v...)
+}
+
+// loggers returns the casbin logger and the leveled logger.
+func (rm *RoleManager) loggers() (log.Logger, Logger) {
+	rm.logMu.RLock()
+	defer rm.logMu.RUnlock()
+
+	return rm.logger, rm.leveledLogger
+}
+
+// pii returns name for logging, redacted if enabled, see WithPIIRedaction.
+func (rm *RoleManager) pii(name string) string {
+	if !rm.redactPII {
+		return name
+	}
+	return redact(name)
+}
+
+// redact masks all but the first character of a name, and the domain of an
+// email, e.g. "a***@example.com".
+func redact(name string) string {
+	if name == "" {
+		return ""
+	}
+	local, domain := name, ""
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		local, domain = name[:i], name[i:]
+	}
+	for _, r := range local {
+		return string(r) + "***" + domain
+	}
+	return "***" + domain
+}
+
+// logPrintf prints a log message if logger is enabled. casbin v2 loggers
+// have no method for free-form messages, so the standard logger is used,
+// as casbin v1 did.
+func logPrintf(logger log.Logger, format string, v ...interface{}) {
+	if logger != nil && logger.IsEnabled() {
+		stdlog.Printf(format, v...)
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/auth0/go-auth0/management"
+)
+
+// Defaults of the cache of the names not found in Auth0, see
+// WithLookupMissTTL.
+const (
+	defaultMissTTL   = 30 * time.Second
+	defaultMaxMisses = 10000
+)
+
+// Prefixes of the keys of the users and roles in a missCache.
+const (
+	missKindUser = "user:"
+	missKindRole = "role:"
+)
+
+// missCache remembers the users and roles recently not found in Auth0, so
+// that unknown names, e.g. in requests of an attacker, do not cost a lookup
+// every time. The oldest names are dropped beyond max.
+type missCache struct {
+	ttl time.Duration
+	max int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type missEntry struct {
+	key     string
+	expires time.Time
+}
+
+func newMissCache(ttl time.Duration, max int) *missCache {
+	return &missCache{ttl: ttl, max: max, entries: map[string]*list.Element{}, order: list.New()}
+}
+
+// has determines whether key was not found in Auth0 within the TTL.
+func (c *missCache) has(key string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	if !now.Before(e.Value.(*missEntry).expires) {
+		c.order.Remove(e)
+		delete(c.entries, key)
+		return false
+	}
+	return true
+}
+
+// add remembers that key was not found in Auth0.
+func (c *missCache) add(key string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+	}
+	c.entries[key] = c.order.PushBack(&missEntry{key: key, expires: now.Add(c.ttl)})
+	for c.order.Len() > c.max {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*missEntry).key)
+	}
+}
+
+// reset forgets all the names.
+func (c *missCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+}
+
+// cachedMiss determines whether a user or role was recently not found in
+// Auth0, counting it in Stats.
+func (rm *RoleManager) cachedMiss(key string) bool {
+	if rm.misses == nil || !rm.misses.has(key, rm.now()) {
+		return false
+	}
+	rm.stats.cachedMisses.Add(1)
+	return true
+}
+
+// addMiss remembers that a user or role was not found in Auth0.
+func (rm *RoleManager) addMiss(key string) {
+	if rm.misses != nil {
+		rm.misses.add(key, rm.now())
+	}
+}
+
+// userID returns the ID of a user. Users missing from the (ID, name)
+// mapping, e.g. because they were created in Auth0 after it was loaded, are
+// looked up in Auth0 and added to it.
+func (rm *RoleManager) userID(ctx context.Context, name string) (string, error) {
+	if id, ok := rm.mappedID(name); ok {
+		return id, nil
+	}
+	if rm.cachedMiss(missKindUser + name) {
+		return "", errors.New("ID not found for the user")
+	}
+	if err := rm.lookupUser(ctx, name); err != nil {
+		return "", err
+	}
+	if id, ok := rm.mappedID(name); ok {
+		return id, nil
+	}
+	rm.addMiss(missKindUser + name)
+	return "", errors.New("ID not found for the user")
+}
+
+// mappedID returns the ID of a user or role from the mapping.
+func (rm *RoleManager) mappedID(name string) (string, bool) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	id, ok := rm.nameToIDMap[name]
+	return id, ok
+}
+
+// roleID returns the ID of a role, looking it up in Auth0 like userID if it
+// is missing from the mapping.
+func (rm *RoleManager) roleID(ctx context.Context, name string) (string, error) {
+	ok, err := rm.hasRole(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", errors.New("ID not found for the role")
+	}
+	id, _ := rm.mappedID(name)
+	return id, nil
+}
+
+// isRole determines whether name is an Auth0 role of the mapping.
+func (rm *RoleManager) isRole(name string) bool {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	return rm.roles[name]
+}
+
+// hasRole determines whether name is an Auth0 role, looking it up in Auth0
+// like roleID if it is missing from the mapping.
+func (rm *RoleManager) hasRole(ctx context.Context, name string) (bool, error) {
+	if rm.isRole(name) {
+		return true, nil
+	}
+	if rm.cachedMiss(missKindRole + name) {
+		return false, nil
+	}
+	if err := rm.lookupRole(ctx, name); err != nil {
+		return false, err
+	}
+	if !rm.isRole(name) {
+		rm.addMiss(missKindRole + name)
+		return false, nil
+	}
+	return true, nil
+}
+
+// lookupUser adds the user named name to the mapping, if any. Users are
+// searched by the identity attribute, see SetUserIdentity.
+func (rm *RoleManager) lookupUser(ctx context.Context, name string) error {
+	rm.mu.RLock()
+	fields := rm.userFields()
+	attribute := rm.searchAttribute()
+	query := rm.userQuery()
+	rm.mu.RUnlock()
+
+	if attribute == "" {
+		return nil
+	}
+	rm.logf(LevelInfo, "Looking up user %s", rm.pii(name))
+
+	users, err := rm.findUsers(ctx, name, attribute, query, fields)
+	if err != nil {
+		return err
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	for _, user := range users {
+		if rm.identity(user) == name {
+			rm.nameToIDMap[name] = user.GetID()
+			rm.idToNameMap[user.GetID()] = name
+			rm.profiles[user.GetID()] = user
+			rm.logf(LevelDebug, "%s -> %s", user.GetID(), rm.pii(name))
+			break
+		}
+	}
+	return nil
+}
+
+// lookupRole adds the Auth0 roles whose names match name to the mapping.
+// The name filter of Auth0 is loose, so that roles named differently in
+// policies by the role name mapping and transform may still be found.
+func (rm *RoleManager) lookupRole(ctx context.Context, name string) error {
+	rm.logf(LevelInfo, "Looking up role %s", name)
+
+	rm.mu.RLock()
+	auth0Name := rm.auth0RoleName(name)
+	rm.mu.RUnlock()
+
+	found := []*management.Role{}
+	for p := 0; ; p++ {
+		roles, _, err := pager(ctx, rm, rm.api.ListRoles, ListOptions{NameFilter: auth0Name}, p)
+		if err != nil {
+			return err
+		}
+		found = append(found, roles.Roles...)
+		if !roles.HasNext() {
+			break
+		}
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	added := false
+	for _, role := range found {
+		if _, ok := rm.auth0Roles[role.GetID()]; !ok {
+			rm.auth0Roles[role.GetID()] = role
+			added = true
+		}
+	}
+	if added {
+		rm.indexRoles()
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestLazyLookup(t *testing.T) {
+	fake := auth0test.New()
+	rm := newFakeRoleManager(t, fake)
+
+	// Users and roles created after the mapping was loaded are looked up.
+	addUser(fake, "auth0|bob", "bob@example.com")
+	addRole(fake, "rol_admin", "admin")
+	addRole(fake, "rol_editor", "editor")
+	assignRoles(t, fake, "auth0|bob", "rol_admin", "rol_editor")
+
+	testPrintRoles(t, rm, "bob@example.com", []string{"admin", "editor"})
+	if rm.nameToIDMap["bob@example.com"] != "auth0|bob" {
+		t.Error("bob@example.com should have been added to the mapping")
+	}
+	testPrintUsers(t, rm, "editor", []string{"bob@example.com"})
+	if !rm.roles["editor"] {
+		t.Error("editor should have been added to the mapping")
+	}
+	if fake.CallCount("ListUsersByEmail") != 1 || fake.CallCount("ListRoles") != 2 {
+		t.Errorf("calls: %v, supposed to be one email search and one role search after the load", fake.Calls())
+	}
+
+	if _, err := rm.GetRoles("carol@example.com"); err == nil {
+		t.Error("carol@example.com should not be found")
+	}
+	if _, err := rm.GetUsers("viewer"); err == nil {
+		t.Error("viewer should not be found")
+	}
+}
+
+func TestLookupMisses(t *testing.T) {
+	fake := auth0test.New()
+	now := time.Now()
+	rm := newFakeRoleManager(t, fake)
+	rm.now = func() time.Time { return now }
+
+	// Unknown users and roles are looked up once within the TTL.
+	for i := 0; i < 3; i++ {
+		if _, err := rm.GetRoles("carol@example.com"); err == nil {
+			t.Error("carol@example.com should not be found")
+		}
+		if _, err := rm.GetUsers("viewer"); err == nil {
+			t.Error("viewer should not be found")
+		}
+	}
+	if fake.CallCount("ListUsersByEmail") != 1 || fake.CallCount("ListRoles") != 2 {
+		t.Errorf("calls: %v, supposed to be one email search and one role search after the load", fake.Calls())
+	}
+	if misses := rm.Stats().CachedMisses; misses != 4 {
+		t.Errorf("cached misses = %d, supposed to be 4", misses)
+	}
+
+	// They are looked up again once the TTL is over.
+	addUser(fake, "auth0|carol", "carol@example.com")
+	now = now.Add(defaultMissTTL)
+	if _, err := rm.GetRoles("carol@example.com"); err != nil {
+		t.Error(err)
+	}
+
+	// A reload forgets the misses.
+	if _, err := rm.GetUsers("viewer"); err == nil {
+		t.Error("viewer should not be found")
+	}
+	addRole(fake, "rol_viewer", "viewer")
+	if err := rm.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rm.GetUsers("viewer"); err != nil {
+		t.Error(err)
+	}
+
+	// Disabled, every lookup calls Auth0.
+	fake = auth0test.New()
+	rm = newFakeRoleManager(t, fake, WithLookupMissTTL(0, 0))
+	for i := 0; i < 3; i++ {
+		if _, err := rm.GetRoles("carol@example.com"); err == nil {
+			t.Error("carol@example.com should not be found")
+		}
+	}
+	if calls := fake.CallCount("ListUsersByEmail"); calls != 3 {
+		t.Errorf("email searches = %d, supposed to be 3", calls)
+	}
+}
+
+func TestMissCacheBound(t *testing.T) {
+	now := time.Now()
+	c := newMissCache(time.Minute, 3)
+	for i := 0; i < 5; i++ {
+		c.add(fmt.Sprint(i), now)
+	}
+	for i := 0; i < 5; i++ {
+		if has, want := c.has(fmt.Sprint(i), now), i >= 2; has != want {
+			t.Errorf("has(%d) = %t, supposed to be %t", i, has, want)
+		}
+	}
+	if c.has("4", now.Add(time.Minute)) {
+		t.Error("4 should have expired")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"sort"
+
+	"github.com/casbin/casbin/v2/rbac"
+)
+
+// Match matches a name with a pattern, with the matching function if one
+// was added, see AddMatchingFunc. Names are otherwise matched exactly.
+func (rm *RoleManager) Match(str string, pattern string) bool {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	return rm.match(str, pattern)
+}
+
+// match is Match. rm.mu must be held.
+func (rm *RoleManager) match(str string, pattern string) bool {
+	if str == pattern {
+		return true
+	}
+	return rm.matchingFunc != nil && rm.matchingFunc(str, pattern)
+}
+
+// AddMatchingFunc sets the matching function of role and user names, e.g.
+// util.KeyMatch2, so that policies can use patterns like role:admin-*.
+// HasLink then accepts a pattern of roles as name2, and a pattern of users
+// as name1 itself, and GetRoles and GetUsers accept patterns of users and
+// roles. Patterns are matched against the names of the (ID, name) mapping,
+// without calls to Auth0, each call scanning all of them: for large tenants,
+// prefer patterns of roles to patterns of users. name is only used in log
+// messages.
+func (rm *RoleManager) AddMatchingFunc(name string, fn rbac.MatchingFunc) {
+	rm.mu.Lock()
+	rm.matchingFunc = fn
+	rm.mu.Unlock()
+
+	rm.logf(LevelInfo, "Matching names with %s", name)
+}
+
+// AddDomainMatchingFunc sets the matching function of domains, e.g.
+// util.KeyMatch, so that domain aliases can be patterns: a domain without
+// an alias of its own takes the alias of the first pattern, in sorted
+// order, it matches. name is only used in log messages.
+func (rm *RoleManager) AddDomainMatchingFunc(name string, fn rbac.MatchingFunc) {
+	rm.mu.Lock()
+	rm.domainMatchingFunc = fn
+	rm.mu.Unlock()
+
+	rm.logf(LevelInfo, "Matching domains with %s", name)
+}
+
+// matchingRoles returns the roles of the mapping matching pattern, sorted,
+// or nil if pattern is a role or no matching function was added. It scans
+// all the roles.
+func (rm *RoleManager) matchingRoles(pattern string) []string {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	if rm.matchingFunc == nil || rm.roles[pattern] {
+		return nil
+	}
+	res := []string{}
+	for role := range rm.roles {
+		if rm.match(role, pattern) {
+			res = append(res, role)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// matchingUsers returns the users of the mapping matching pattern, sorted,
+// or nil if pattern is a user or no matching function was added. It scans
+// the whole mapping, in O(users) calls of the matching function.
+func (rm *RoleManager) matchingUsers(pattern string) []string {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	if rm.matchingFunc == nil {
+		return nil
+	}
+	if _, ok := rm.nameToIDMap[pattern]; ok {
+		return nil
+	}
+	res := []string{}
+	for name := range rm.nameToIDMap {
+		if !rm.roles[name] && rm.match(name, pattern) {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// matchDomainAlias returns the alias of the first domain alias pattern
+// domain matches. rm.mu must be held.
+func (rm *RoleManager) matchDomainAlias(domain string) (string, bool) {
+	if rm.domainMatchingFunc == nil {
+		return "", false
+	}
+	for _, pattern := range sortedNames(rm.domainAliases) {
+		if rm.domainMatchingFunc(domain, pattern) {
+			return rm.domainAliases[pattern], true
+		}
+	}
+	return "", false
+}
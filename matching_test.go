@@ -0,0 +1,72 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"path"
+	"testing"
+
+	"github.com/casbin/casbin/v2/util"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func globMatch(str string, pattern string) bool {
+	ok, _ := path.Match(pattern, str)
+	return ok
+}
+
+func TestMatchingFunc(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	addUser(fake, "auth0|bob", "bob@example.com")
+	addUser(fake, "auth0|carol", "carol@test.com")
+	addRole(fake, "rol_admin_eu", "admin-eu")
+	addRole(fake, "rol_admin_us", "admin-us")
+	addRole(fake, "rol_editor", "editor")
+	assignRoles(t, fake, "auth0|alice", "rol_admin_eu")
+	assignRoles(t, fake, "auth0|bob", "rol_editor")
+	assignRoles(t, fake, "auth0|carol", "rol_admin_us")
+	rm := newFakeRoleManager(t, fake, WithMatchingFunc("glob", globMatch))
+	loadCalls := len(fake.Calls())
+
+	if !rm.Match("admin-eu", "admin-*") || rm.Match("editor", "admin-*") {
+		t.Error("Match should use the matching function")
+	}
+
+	// Users matching the pattern themselves need no call to Auth0.
+	if ok, _ := rm.HasLink("alice@example.com", "*@example.com"); !ok {
+		t.Error("alice@example.com < *@example.com: false, supposed to be true")
+	}
+	if n := len(fake.Calls()) - loadCalls; n != 0 {
+		t.Errorf("%d calls to Auth0, supposed to be none", n)
+	}
+
+	if ok, _ := rm.HasLink("alice@example.com", "admin-*"); !ok {
+		t.Error("alice@example.com < admin-*: false, supposed to be true")
+	}
+	if ok, _ := rm.HasLink("bob@example.com", "admin-*"); ok {
+		t.Error("bob@example.com < admin-*: true, supposed to be false")
+	}
+	testPrintUsers(t, rm, "admin-*", []string{"alice@example.com", "carol@test.com"})
+	testPrintRoles(t, rm, "*@example.com", []string{"admin-eu", "editor"})
+
+	rm.EnableOrganizations(true)
+	rm.AddDomainAlias("acme-*", "org_acme")
+	rm.AddDomainMatchingFunc("KeyMatch", util.KeyMatch)
+	if d, err := rm.resolveDomain("acme-eu"); err != nil || d != "org_acme" {
+		t.Errorf("acme-eu: %s, supposed to be org_acme", d)
+	}
+}
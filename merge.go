@@ -0,0 +1,301 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"errors"
+
+	"github.com/casbin/casbin/v2/log"
+	"github.com/casbin/casbin/v2/rbac"
+)
+
+// Source is a role manager taking part in a MergeRoleManager.
+type Source struct {
+	// Name tags the results coming from this source, e.g. "auth0".
+	Name string
+	// Manager is the role manager of this source.
+	Manager rbac.RoleManager
+	// Required makes the whole lookup fail when this source fails. Errors of
+	// other sources are logged and the source is left out of the results.
+	Required bool
+	// Writable makes the links added and deleted through the merge go to
+	// this source, e.g. local overrides. Marking the Auth0 role manager
+	// writable assigns every grouping rule of the casbin policy in Auth0
+	// when the policy is loaded.
+	Writable bool
+}
+
+// TaggedName is a role or user name returned by a MergeRoleManager together
+// with the sources it comes from.
+type TaggedName struct {
+	Name string
+	// Source is the source with the highest precedence returning the name.
+	Source string
+	// Sources are all the sources returning the name, by precedence.
+	Sources []string
+}
+
+// MergeRoleManager is a role manager returning the union of the roles and
+// users of several sources, e.g. Auth0 roles, Authorization Extension groups
+// and local overrides.
+//
+// Sources are listed by precedence, highest first. When several sources
+// return the same name, it is reported once and tagged with the source
+// having the highest precedence. Links are added to and deleted from the
+// writable source with the highest precedence accepting them, see
+// Source.Writable.
+type MergeRoleManager struct {
+	sources []Source
+	logger  log.Logger
+}
+
+// NewMergeRoleManager is the constructor of a MergeRoleManager.
+// sources are listed by precedence, highest first.
+func NewMergeRoleManager(sources ...Source) rbac.RoleManager {
+	return &MergeRoleManager{sources: sources, logger: &log.DefaultLogger{}}
+}
+
+// Clear clears all the sources.
+func (rm *MergeRoleManager) Clear() error {
+	var err error
+	for _, s := range rm.sources {
+		if sourceErr := s.Manager.Clear(); err == nil {
+			err = sourceErr
+		}
+	}
+	return err
+}
+
+// BuildRelationship is a no-op kept for the casbin v2 interface.
+//
+// Deprecated: BuildRelationship is no longer required by casbin.
+func (rm *MergeRoleManager) BuildRelationship(name1 string, name2 string, domain ...string) error {
+	return nil
+}
+
+// AddLink adds the inheritance link to the first writable source accepting
+// it.
+func (rm *MergeRoleManager) AddLink(name1 string, name2 string, domain ...string) error {
+	return rm.write(func(m rbac.RoleManager) error {
+		return m.AddLink(name1, name2, domain...)
+	})
+}
+
+// DeleteLink deletes the inheritance link from the first writable source
+// accepting it.
+func (rm *MergeRoleManager) DeleteLink(name1 string, name2 string, domain ...string) error {
+	return rm.write(func(m rbac.RoleManager) error {
+		return m.DeleteLink(name1, name2, domain...)
+	})
+}
+
+func (rm *MergeRoleManager) write(f func(rbac.RoleManager) error) error {
+	err := errors.New("error: no writable source, see Source.Writable")
+	for _, s := range rm.sources {
+		if !s.Writable {
+			continue
+		}
+		if err = f(s.Manager); err == nil {
+			return nil
+		}
+		logPrintf(rm.logger, "Source %s rejected the link: '%v'", s.Name, err)
+	}
+	return err
+}
+
+// HasLink determines whether role: name1 inherits role: name2 in any source.
+func (rm *MergeRoleManager) HasLink(name1 string, name2 string, domain ...string) (bool, error) {
+	failures := 0
+	var firstErr error
+	for _, s := range rm.sources {
+		res, err := s.Manager.HasLink(name1, name2, domain...)
+		if err != nil {
+			if s.Required {
+				return false, err
+			}
+			logPrintf(rm.logger, "Source %s failed: '%v'", s.Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			failures++
+			continue
+		}
+		if res {
+			return true, nil
+		}
+	}
+	if failures == len(rm.sources) {
+		return false, firstErr
+	}
+	return false, nil
+}
+
+// GetRoles gets the union of the roles that a subject inherits in all sources.
+func (rm *MergeRoleManager) GetRoles(name string, domain ...string) ([]string, error) {
+	return names(rm.GetRolesWithSources(name, domain...))
+}
+
+// GetUsers gets the union of the users that inherits a subject in all sources.
+func (rm *MergeRoleManager) GetUsers(name string, domain ...string) ([]string, error) {
+	return names(rm.GetUsersWithSources(name, domain...))
+}
+
+// GetImplicitRoles gets the union of the implicit roles that a subject
+// inherits in all sources.
+func (rm *MergeRoleManager) GetImplicitRoles(name string, domain ...string) ([]string, error) {
+	return names(rm.merge(func(m rbac.RoleManager) ([]string, error) {
+		return m.GetImplicitRoles(name, domain...)
+	}))
+}
+
+// GetImplicitUsers gets the union of the implicit users that inherits a
+// subject in all sources.
+func (rm *MergeRoleManager) GetImplicitUsers(name string, domain ...string) ([]string, error) {
+	return names(rm.merge(func(m rbac.RoleManager) ([]string, error) {
+		return m.GetImplicitUsers(name, domain...)
+	}))
+}
+
+// GetDomains gets the union of the domains that a user has in all sources.
+func (rm *MergeRoleManager) GetDomains(name string) ([]string, error) {
+	return names(rm.merge(func(m rbac.RoleManager) ([]string, error) {
+		return m.GetDomains(name)
+	}))
+}
+
+// GetAllDomains gets the union of the domains of all sources.
+func (rm *MergeRoleManager) GetAllDomains() ([]string, error) {
+	return names(rm.merge(func(m rbac.RoleManager) ([]string, error) {
+		return m.GetAllDomains()
+	}))
+}
+
+// DeleteDomain deletes the data of a domain from all the sources.
+func (rm *MergeRoleManager) DeleteDomain(domain string) error {
+	var err error
+	for _, s := range rm.sources {
+		if sourceErr := s.Manager.DeleteDomain(domain); err == nil {
+			err = sourceErr
+		}
+	}
+	return err
+}
+
+// GetRolesWithSources gets the union of the roles that a subject inherits,
+// tagged with the sources they come from.
+func (rm *MergeRoleManager) GetRolesWithSources(name string, domain ...string) ([]TaggedName, error) {
+	return rm.merge(func(m rbac.RoleManager) ([]string, error) {
+		return m.GetRoles(name, domain...)
+	})
+}
+
+// GetUsersWithSources gets the union of the users that inherits a subject,
+// tagged with the sources they come from.
+func (rm *MergeRoleManager) GetUsersWithSources(name string, domain ...string) ([]TaggedName, error) {
+	return rm.merge(func(m rbac.RoleManager) ([]string, error) {
+		return m.GetUsers(name, domain...)
+	})
+}
+
+func (rm *MergeRoleManager) merge(f func(rbac.RoleManager) ([]string, error)) ([]TaggedName, error) {
+	res := []TaggedName{}
+	index := map[string]int{}
+	failures := 0
+	var firstErr error
+
+	for _, s := range rm.sources {
+		names, err := f(s.Manager)
+		if err != nil {
+			if s.Required {
+				return nil, err
+			}
+			logPrintf(rm.logger, "Source %s failed: '%v'", s.Name, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			failures++
+			continue
+		}
+
+		for _, name := range names {
+			if i, ok := index[name]; ok {
+				res[i].Sources = append(res[i].Sources, s.Name)
+				continue
+			}
+			index[name] = len(res)
+			res = append(res, TaggedName{Name: name, Source: s.Name, Sources: []string{s.Name}})
+		}
+	}
+
+	if len(rm.sources) > 0 && failures == len(rm.sources) {
+		return nil, firstErr
+	}
+	return res, nil
+}
+
+// PrintRoles prints the roles of all the sources to log.
+func (rm *MergeRoleManager) PrintRoles() error {
+	var err error
+	for _, s := range rm.sources {
+		logPrintf(rm.logger, "Source %s:", s.Name)
+		if sourceErr := s.Manager.PrintRoles(); err == nil {
+			err = sourceErr
+		}
+	}
+	return err
+}
+
+// SetLogger sets the logger of the merge and of all the sources.
+func (rm *MergeRoleManager) SetLogger(logger log.Logger) {
+	rm.logger = logger
+	for _, s := range rm.sources {
+		s.Manager.SetLogger(logger)
+	}
+}
+
+// Match matches a name with a pattern in any source.
+func (rm *MergeRoleManager) Match(str string, pattern string) bool {
+	for _, s := range rm.sources {
+		if s.Manager.Match(str, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddMatchingFunc adds the matching function to all the sources.
+func (rm *MergeRoleManager) AddMatchingFunc(name string, fn rbac.MatchingFunc) {
+	for _, s := range rm.sources {
+		s.Manager.AddMatchingFunc(name, fn)
+	}
+}
+
+// AddDomainMatchingFunc adds the domain matching function to all the sources.
+func (rm *MergeRoleManager) AddDomainMatchingFunc(name string, fn rbac.MatchingFunc) {
+	for _, s := range rm.sources {
+		s.Manager.AddDomainMatchingFunc(name, fn)
+	}
+}
+
+func names(tagged []TaggedName, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(tagged))
+	for _, t := range tagged {
+		res = append(res, t.Name)
+	}
+	return res, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	defaultrolemanager "github.com/casbin/casbin/v2/rbac/default-role-manager"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestMergeRoleManager(t *testing.T) {
+	overrides := defaultrolemanager.NewRoleManager(10)
+	groups := defaultrolemanager.NewRoleManager(10)
+	_ = overrides.AddLink("alice@test.com", "Admin")
+	_ = groups.AddLink("alice@test.com", "Group1")
+	_ = groups.AddLink("alice@test.com", "Admin")
+
+	rm := NewMergeRoleManager(
+		Source{Name: "overrides", Manager: overrides, Writable: true},
+		Source{Name: "groups", Manager: groups},
+	)
+
+	testRole(t, rm, "alice@test.com", "Group1", true)
+	testRole(t, rm, "alice@test.com", "Admin", true)
+	testPrintRoles(t, rm, "alice@test.com", []string{"Admin", "Group1"})
+
+	tagged, err := rm.(*MergeRoleManager).GetRolesWithSources("alice@test.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagged[0].Name != "Admin" || tagged[0].Source != "overrides" || len(tagged[0].Sources) != 2 {
+		t.Errorf("Admin: %+v, supposed to come from overrides and groups", tagged[0])
+	}
+	if tagged[1].Name != "Group1" || tagged[1].Source != "groups" {
+		t.Errorf("Group1: %+v, supposed to come from groups", tagged[1])
+	}
+
+	// Links go to the writable source with the highest precedence.
+	_ = rm.AddLink("bob@test.com", "Group1")
+	testPrintUsers(t, overrides, "Group1", []string{"bob@test.com"})
+
+	// A source not knowing a name does not fail the union.
+	testPrintUsers(t, rm, "Group1", []string{"bob@test.com", "alice@test.com"})
+}
+
+func TestMergeWrites(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|bob", "bob@example.com")
+	addRole(fake, "rol_admin", "admin")
+	primary := newFakeRoleManager(t, fake)
+	overrides := defaultrolemanager.NewRoleManager(10)
+
+	// The grouping rules of a loaded policy go to the local source.
+	rm := NewMergeRoleManager(
+		Source{Name: "auth0", Manager: primary},
+		Source{Name: "overrides", Manager: overrides, Writable: true},
+	)
+	policy := filepath.Join(t.TempDir(), "policy.csv")
+	if err := os.WriteFile(policy, []byte("p, admin, data2, write\ng, bob@example.com, admin\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e, err := casbin.NewEnforcer("examples/rbac_model.conf", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.SetRoleManager(rm)
+	if err := e.LoadPolicy(); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := e.Enforce("bob@example.com", "data2", "write"); !ok {
+		t.Error("bob@example.com should be granted data2 by the policy")
+	}
+	if calls := fake.CallCount("AssignUserRoles") + fake.CallCount("CreateRole"); calls != 0 {
+		t.Errorf("calls: %v, supposed to assign and create no role", fake.Calls())
+	}
+
+	// Without a writable source, links are rejected.
+	rm = NewMergeRoleManager(Source{Name: "auth0", Manager: primary})
+	if err := rm.AddLink("bob@example.com", "admin"); err == nil {
+		t.Error("links should be rejected without a writable source")
+	}
+
+	// Writes to Auth0 are opt-in.
+	rm = NewMergeRoleManager(Source{Name: "auth0", Manager: primary, Writable: true})
+	if err := rm.AddLink("bob@example.com", "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.CallCount("AssignUserRoles"); calls != 1 {
+		t.Errorf("role assignments: %d, supposed to be 1", calls)
+	}
+}
@@ -0,0 +1,424 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/casbin/casbin/v2/rbac"
+	"golang.org/x/oauth2"
+)
+
+// defaultPageSize is the number of items per page of the Auth0 list calls,
+// the maximum allowed by the Management API.
+const defaultPageSize = 100
+
+// Option configures a RoleManager created by NewRoleManagerWithOptions.
+type Option func(rm *RoleManager) error
+
+// WithDomain sets the domain of the Auth0 tenant, e.g. "abc.eu.auth0.com"
+// for a regional tenant or "login.example.com" for a custom domain, instead
+// of deriving abc.auth0.com from the tenant name, which may then be empty.
+func WithDomain(domain string) Option {
+	return func(rm *RoleManager) error {
+		u, err := url.Parse("https://" + domain)
+		if err != nil || domain == "" || u.Host != domain {
+			return fmt.Errorf("error: invalid Auth0 domain %q, supposed to be a host name like abc.eu.auth0.com", domain)
+		}
+		rm.domain = domain
+		return nil
+	}
+}
+
+// WithIssuerURL sets the domain of the Auth0 tenant from its issuer URL, as
+// found in ID tokens and in the OpenID configuration, e.g.
+// "https://abc.eu.auth0.com/". See WithDomain.
+func WithIssuerURL(issuer string) Option {
+	return func(rm *RoleManager) error {
+		u, err := url.Parse(issuer)
+		if err != nil || u.Scheme != "https" || u.Host == "" || strings.Trim(u.Path, "/") != "" {
+			return fmt.Errorf("error: invalid Auth0 issuer URL %q, supposed to be like https://abc.eu.auth0.com/", issuer)
+		}
+		rm.domain = u.Host
+		return nil
+	}
+}
+
+// WithStaticToken authenticates the Management API calls with a token
+// obtained externally, instead of the client credentials, which may then be
+// empty. The token is not renewed; use WithTokenSource for short-lived
+// tokens.
+func WithStaticToken(token string) Option {
+	return func(rm *RoleManager) error {
+		if token == "" {
+			return errors.New("error: the static token should not be empty")
+		}
+		if rm.tokenSource != nil {
+			return errors.New("error: a static token and a token source cannot be used together")
+		}
+		rm.token = token
+		return nil
+	}
+}
+
+// WithTokenSource authenticates the Management API calls with the tokens of
+// source, e.g. minted by a vault or a workload identity, instead of the
+// client credentials, which may then be empty. Tokens are reused until they
+// expire.
+func WithTokenSource(source oauth2.TokenSource) Option {
+	return func(rm *RoleManager) error {
+		if source == nil {
+			return errors.New("error: the token source should not be nil")
+		}
+		if rm.token != "" {
+			return errors.New("error: a static token and a token source cannot be used together")
+		}
+		rm.tokenSource = oauth2.ReuseTokenSource(nil, source)
+		return nil
+	}
+}
+
+// WithPageSize sets the number of items requested per page from Auth0,
+// between 1 and 100.
+func WithPageSize(n int) Option {
+	return func(rm *RoleManager) error {
+		if n < 1 || n > defaultPageSize {
+			return errors.New("error: page size should be between 1 and 100")
+		}
+		rm.pageSize = n
+		return nil
+	}
+}
+
+// WithLoadConcurrency sets how many pages of users or roles are fetched
+// at once when loading the (ID, name) mapping, 4 by default.
+func WithLoadConcurrency(n int) Option {
+	return func(rm *RoleManager) error {
+		if n < 1 {
+			return errors.New("error: load concurrency should be at least 1")
+		}
+		rm.loadConcurrency = n
+		return nil
+	}
+}
+
+// WithLoadProgress sets a function called after every page fetched when
+// loading the (ID, name) mapping, e.g. to report the progress of the first
+// load of large tenants. It is not called concurrently.
+func WithLoadProgress(progress func(LoadProgress)) Option {
+	return func(rm *RoleManager) error {
+		rm.loadProgress = progress
+		return nil
+	}
+}
+
+// WithoutPreload skips the loading of the (ID, name) mapping in the
+// constructor. Call Load to load it later.
+func WithoutPreload() Option {
+	return func(rm *RoleManager) error {
+		rm.preload = false
+		return nil
+	}
+}
+
+// WithManagementAPI makes the role manager call api instead of the Auth0
+// Management API, e.g. the in-memory fake of the auth0test package in tests.
+// The credentials, tenant and HTTP options are then not used, and
+// ManagementClient returns nil.
+func WithManagementAPI(api ManagementAPI) Option {
+	return func(rm *RoleManager) error {
+		if api == nil {
+			return errors.New("error: the management API should not be nil")
+		}
+		rm.api = api
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for the Management API calls,
+// e.g. to use a proxy or custom transport.
+func WithHTTPClient(client *http.Client) Option {
+	return func(rm *RoleManager) error {
+		rm.httpClient = client
+		return nil
+	}
+}
+
+// WithRequestTimeout sets the timeout of each Management API request, and of
+// the download of the users exports of large tenants.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(rm *RoleManager) error {
+		rm.requestTimeout = timeout
+		return nil
+	}
+}
+
+// WithMaxRetries sets how many times a request rate limited by the
+// Management API is retried, 5 by default. Once exhausted, the call fails
+// with a *RateLimitError.
+func WithMaxRetries(n int) Option {
+	return func(rm *RoleManager) error {
+		if n < 0 {
+			return errors.New("error: max retries should not be negative")
+		}
+		rm.maxRetries = n
+		return nil
+	}
+}
+
+// WithMaxRetryWait sets the longest wait before retrying a rate limited
+// request, 30 seconds by default. The wait honors the X-RateLimit-Reset
+// header returned by Auth0, and otherwise backs off exponentially.
+func WithMaxRetryWait(wait time.Duration) Option {
+	return func(rm *RoleManager) error {
+		if wait <= 0 {
+			return errors.New("error: max retry wait should be positive")
+		}
+		rm.maxRetryWait = wait
+		return nil
+	}
+}
+
+// WithLeveledLogger sets a logger receiving the log messages of the role
+// manager with their level, instead of printing them with the standard log
+// package when the casbin logger is enabled, see SetLogger.
+func WithLeveledLogger(logger Logger) Option {
+	return func(rm *RoleManager) error {
+		rm.leveledLogger = logger
+		return nil
+	}
+}
+
+// WithPIIRedaction masks the user names and emails in the log messages,
+// e.g. "a***@example.com".
+func WithPIIRedaction() Option {
+	return func(rm *RoleManager) error {
+		rm.redactPII = true
+		return nil
+	}
+}
+
+// WithAPICallHook sets a function called with every Management API request
+// once it is done, e.g. to monitor the call volume and latency. It is called
+// concurrently, from the goroutines making the calls. See also Stats.
+func WithAPICallHook(hook func(APICall)) Option {
+	return func(rm *RoleManager) error {
+		rm.apiCallHook = hook
+		return nil
+	}
+}
+
+// WithMappingTTL sets how long the (ID, name) mapping is used before it is
+// considered stale and reloaded from Auth0 in the background on the next
+// lookup, the stale mapping being used until the reload is done. Zero, the
+// default, keeps it until Refresh or Clear is called.
+func WithMappingTTL(ttl time.Duration) Option {
+	return func(rm *RoleManager) error {
+		rm.mappingTTL = ttl
+		return nil
+	}
+}
+
+// WithLookupMissTTL sets how long users and roles not found in Auth0 are
+// remembered, answering their lookups without calling Auth0, see
+// Stats.CachedMisses. The default is 30 seconds, for up to maxEntries
+// names. Zero disables it, so that every lookup of an unknown name calls
+// Auth0.
+func WithLookupMissTTL(ttl time.Duration, maxEntries int) Option {
+	return func(rm *RoleManager) error {
+		if ttl <= 0 || maxEntries <= 0 {
+			rm.misses = nil
+			return nil
+		}
+		rm.misses = newMissCache(ttl, maxEntries)
+		return nil
+	}
+}
+
+// WithRefreshInterval reloads the (ID, name) mapping from Auth0 in the
+// background every interval, until Close is called.
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(rm *RoleManager) error {
+		rm.refreshInterval = interval
+		return nil
+	}
+}
+
+// WithUserFields restricts the user fields fetched when loading the
+// mapping, see SetUserFields.
+func WithUserFields(fields ...string) Option {
+	return func(rm *RoleManager) error {
+		rm.SetUserFields(fields...)
+		return nil
+	}
+}
+
+// WithUserQuery restricts the users loaded to those matching a query, see
+// SetUserQuery.
+func WithUserQuery(query string) Option {
+	return func(rm *RoleManager) error {
+		rm.SetUserQuery(query)
+		return nil
+	}
+}
+
+// WithUserConnections restricts the users loaded to those of the given
+// connections, see SetUserConnections.
+func WithUserConnections(connections ...string) Option {
+	return func(rm *RoleManager) error {
+		rm.SetUserConnections(connections...)
+		return nil
+	}
+}
+
+// WithUserIdentity sets the user attribute used as user name in policies,
+// see SetUserIdentity.
+func WithUserIdentity(attribute string) Option {
+	return func(rm *RoleManager) error {
+		return rm.SetUserIdentity(attribute)
+	}
+}
+
+// WithIdentityResolver sets a custom function naming users in policies, see
+// SetIdentityResolver.
+func WithIdentityResolver(resolver IdentityResolver) Option {
+	return func(rm *RoleManager) error {
+		rm.SetIdentityResolver(resolver)
+		return nil
+	}
+}
+
+// WithAccountStateRoles enables the account state synthetic roles, see
+// EnableAccountStateRoles.
+func WithAccountStateRoles() Option {
+	return func(rm *RoleManager) error {
+		rm.EnableAccountStateRoles(true)
+		return nil
+	}
+}
+
+// WithMetadataRules sets the metadata rules, see SetMetadataRules.
+func WithMetadataRules(rules ...MetadataRule) Option {
+	return func(rm *RoleManager) error {
+		return rm.SetMetadataRules(rules...)
+	}
+}
+
+// WithSyntheticRoleProvider sets the synthetic role provider, see
+// SetSyntheticRoleProvider.
+func WithSyntheticRoleProvider(provider SyntheticRoleProvider) Option {
+	return func(rm *RoleManager) error {
+		rm.SetSyntheticRoleProvider(provider)
+		return nil
+	}
+}
+
+// WithRoleNameTransform sets the role name transform, see SetRoleNameTransform.
+func WithRoleNameTransform(transform RoleNameTransform) Option {
+	return func(rm *RoleManager) error {
+		rm.SetRoleNameTransform(transform)
+		return nil
+	}
+}
+
+// WithRoleNameMapping sets the role name mapping, see SetRoleNameMapping.
+func WithRoleNameMapping(mapping map[string]string) Option {
+	return func(rm *RoleManager) error {
+		rm.SetRoleNameMapping(mapping)
+		return nil
+	}
+}
+
+// WithRoleExclusions sets the role exclusions, see SetRoleExclusions.
+func WithRoleExclusions(patterns ...string) Option {
+	return func(rm *RoleManager) error {
+		return rm.SetRoleExclusions(patterns...)
+	}
+}
+
+// WithHierarchyStore sets the store of the local role hierarchy, see
+// SetHierarchyStore.
+func WithHierarchyStore(store HierarchyStore) Option {
+	return func(rm *RoleManager) error {
+		return rm.SetHierarchyStore(store)
+	}
+}
+
+// WithMaxHierarchyLevel sets the number of links of the local role hierarchy
+// followed, see SetMaxHierarchyLevel.
+func WithMaxHierarchyLevel(level int) Option {
+	return func(rm *RoleManager) error {
+		return rm.SetMaxHierarchyLevel(level)
+	}
+}
+
+// WithPermissionLinks makes HasLink check Auth0 permissions, see
+// EnablePermissionLinks. resourceServers are the identifiers of the resource
+// servers that are not URLs, see SetPermissionResourceServers.
+func WithPermissionLinks(resourceServers ...string) Option {
+	return func(rm *RoleManager) error {
+		rm.EnablePermissionLinks(true)
+		rm.SetPermissionResourceServers(resourceServers...)
+		return nil
+	}
+}
+
+// WithMatchingFunc sets the matching function of role and user names, see
+// AddMatchingFunc.
+func WithMatchingFunc(name string, fn rbac.MatchingFunc) Option {
+	return func(rm *RoleManager) error {
+		rm.AddMatchingFunc(name, fn)
+		return nil
+	}
+}
+
+// WithDomainMatchingFunc sets the matching function of domains, see
+// AddDomainMatchingFunc.
+func WithDomainMatchingFunc(name string, fn rbac.MatchingFunc) Option {
+	return func(rm *RoleManager) error {
+		rm.AddDomainMatchingFunc(name, fn)
+		return nil
+	}
+}
+
+// WithOrganizations makes the domain argument an Auth0 Organization, see
+// EnableOrganizations.
+func WithOrganizations() Option {
+	return func(rm *RoleManager) error {
+		rm.EnableOrganizations(true)
+		return nil
+	}
+}
+
+// WithDomainAliases sets the domain alias table, see SetDomainAliases.
+func WithDomainAliases(aliases map[string]string) Option {
+	return func(rm *RoleManager) error {
+		rm.SetDomainAliases(aliases)
+		return nil
+	}
+}
+
+// WithDomainResolver sets the domain resolver, see SetDomainResolver.
+func WithDomainResolver(resolver DomainResolver) Option {
+	return func(rm *RoleManager) error {
+		rm.SetDomainResolver(resolver)
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewRoleManagerWithOptions(t *testing.T) {
+	m, err := NewRoleManagerWithOptions("your_client_id", "your_client_secret", "your_tenant_name",
+		WithoutPreload(),
+		WithPageSize(50),
+		WithRequestTimeout(time.Second),
+		WithAccountStateRoles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rm := m.(*RoleManager)
+	if rm.pageSize != 50 || !rm.accountStateRoles {
+		t.Errorf("options were not applied: page size %d, account state roles %t", rm.pageSize, rm.accountStateRoles)
+	}
+	if ready, _ := rm.IsReady(); ready {
+		t.Error("role manager should not be ready without preload")
+	}
+
+	_, err = NewRoleManagerWithOptions("your_client_id", "your_client_secret", "your_tenant_name",
+		WithoutPreload(),
+		WithPageSize(1000))
+	if err == nil {
+		t.Error("invalid page size should be rejected")
+	}
+}
+
+func TestDomainOptions(t *testing.T) {
+	tests := []struct {
+		tenant string
+		opt    Option
+		domain string
+	}{
+		{"abc", nil, "abc.auth0.com"},
+		{"abc.eu.auth0.com", nil, "abc.eu.auth0.com"},
+		{"", WithDomain("login.example.com"), "login.example.com"},
+		{"abc", WithIssuerURL("https://abc.au.auth0.com/"), "abc.au.auth0.com"},
+		{"", WithDomain("https://abc.auth0.com"), ""},
+		{"", WithIssuerURL("abc.auth0.com"), ""},
+		{"", nil, ""},
+	}
+	for _, test := range tests {
+		opts := []Option{WithoutPreload()}
+		if test.opt != nil {
+			opts = append(opts, test.opt)
+		}
+		rm, err := newRoleManager("your_client_id", "your_client_secret", test.tenant, opts...)
+		if test.domain == "" {
+			if err == nil {
+				t.Errorf("tenant %q: domain %s, supposed to be rejected", test.tenant, rm.domain)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("tenant %q: %v", test.tenant, err)
+			continue
+		}
+		if rm.domain != test.domain {
+			t.Errorf("tenant %q: domain %s, supposed to be %s", test.tenant, rm.domain, test.domain)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/auth0/go-auth0/management"
+)
+
+// EnableOrganizations makes the domain argument of the role manager methods
+// an Auth0 Organization, so that multi-tenant models with "g = _, _, _" can
+// be used. Roles are then those assigned to the members of the
+// organization, and links are added to and deleted from them. Domains are
+// resolved through the domain aliases first, and name an organization by
+// its name or its ID ("org_...").
+//
+// Links between two roles are not domain-specific: the local role hierarchy
+// applies to all the organizations.
+func (rm *RoleManager) EnableOrganizations(enable bool) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.organizations = enable
+}
+
+func (rm *RoleManager) organizationsEnabled() bool {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	return rm.organizations
+}
+
+// organizationID returns the ID of the organization named by the optional
+// domain argument, or "" when no domain is given.
+func (rm *RoleManager) organizationID(ctx context.Context, domain ...string) (string, error) {
+	d, err := rm.resolveDomain(domain...)
+	if err != nil || d == "" {
+		return "", err
+	}
+
+	rm.mu.RLock()
+	enabled := rm.organizations
+	id, ok := rm.orgIDs[d]
+	rm.mu.RUnlock()
+
+	if !enabled {
+		return "", errors.New("error: domain should not be used")
+	}
+	if ok {
+		return id, nil
+	}
+	if strings.HasPrefix(d, "org_") {
+		return d, nil
+	}
+
+	var org *management.Organization
+	err = rm.call(ctx, func() error {
+		var err error
+		org, err = rm.api.ReadOrganizationByName(ctx, d)
+		return err
+	})
+	if mErr, ok := err.(management.Error); ok && mErr.Status() == http.StatusNotFound {
+		return "", fmt.Errorf("error: organization %s not found", d)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if rm.orgIDs == nil {
+		rm.orgIDs = map[string]string{}
+	}
+	rm.orgIDs[d] = org.GetID()
+	return org.GetID(), nil
+}
+
+// getOrganizationMemberRoles gets the roles of a member of an organization.
+func (rm *RoleManager) getOrganizationMemberRoles(ctx context.Context, orgID string, userID string) ([]RoleInfo, error) {
+	res := []RoleInfo{}
+
+	f := func(ctx context.Context, opts ListOptions) (*management.OrganizationMemberRoleList, error) {
+		return rm.api.OrganizationMemberRoles(ctx, orgID, userID, opts)
+	}
+	for p := 0; ; p++ {
+		roles, _, err := pager(ctx, rm, f, ListOptions{}, p)
+		if err != nil {
+			return nil, err
+		}
+		rm.mu.RLock()
+		for _, role := range roles.Roles {
+			if info, ok := rm.auth0RoleInfo(role.GetID(), role.GetName(), role.GetDescription()); ok {
+				res = append(res, info)
+			}
+		}
+		rm.mu.RUnlock()
+		if !roles.HasNext() {
+			break
+		}
+	}
+	return res, nil
+}
+
+// getOrganizationRoleMembers gets the members of an organization having a
+// role. Auth0 has no endpoint for this, so the roles of every member are
+// fetched, which takes one call per member.
+func (rm *RoleManager) getOrganizationRoleMembers(ctx context.Context, orgID string, roleID string) ([]UserInfo, error) {
+	res := []UserInfo{}
+
+	members, err := rm.getOrganizationMembers(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		roles, err := rm.getOrganizationMemberRoles(ctx, orgID, member.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range roles {
+			if role.ID == roleID {
+				res = append(res, member)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// getOrganizationMembers gets the members of an organization.
+func (rm *RoleManager) getOrganizationMembers(ctx context.Context, orgID string) ([]UserInfo, error) {
+	res := []UserInfo{}
+
+	f := func(ctx context.Context, opts ListOptions) (*management.OrganizationMemberList, error) {
+		return rm.api.OrganizationMembers(ctx, orgID, opts)
+	}
+	for p := 0; ; p++ {
+		members, _, err := pager(ctx, rm, f, ListOptions{}, p)
+		if err != nil {
+			return nil, err
+		}
+		rm.mu.RLock()
+		for _, member := range members.Members {
+			user := &management.User{ID: member.UserID, Email: member.Email, Name: member.Name}
+			res = append(res, UserInfo{
+				ID:      member.GetUserID(),
+				Name:    rm.userName(user),
+				Email:   member.GetEmail(),
+				Source:  SourceAuth0,
+				Origins: []Origin{{Source: SourceAuth0}},
+			})
+		}
+		rm.mu.RUnlock()
+		if !members.HasNext() {
+			break
+		}
+	}
+	return res, nil
+}
+
+// listOrganizations returns the names of the organizations listed by f,
+// remembering their IDs.
+func (rm *RoleManager) listOrganizations(ctx context.Context, f func(context.Context, ListOptions) (*management.OrganizationList, error)) ([]string, error) {
+	res := []string{}
+	ids := map[string]string{}
+	for p := 0; ; p++ {
+		orgs, _, err := pager(ctx, rm, f, ListOptions{}, p)
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range orgs.Organizations {
+			res = append(res, org.GetName())
+			ids[org.GetName()] = org.GetID()
+		}
+		if !orgs.HasNext() {
+			break
+		}
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if rm.orgIDs == nil {
+		rm.orgIDs = map[string]string{}
+	}
+	for name, id := range ids {
+		rm.orgIDs[name] = id
+	}
+	return res, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/auth0/go-auth0"
+	"github.com/auth0/go-auth0/management"
+	"github.com/casbin/casbin/v2/util"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestOrganizations(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	addUser(fake, "auth0|bob", "bob@example.com")
+	addRole(fake, "rol_admin", "admin")
+	addRole(fake, "rol_editor", "editor")
+	fake.AddOrganization(&management.Organization{ID: auth0.String("org_acme"), Name: auth0.String("acme")}, "auth0|alice", "auth0|bob")
+	fake.AddOrganization(&management.Organization{ID: auth0.String("org_globex"), Name: auth0.String("globex")})
+	if err := fake.AssignOrganizationMemberRoles(context.Background(), "org_acme", "auth0|alice", []string{"rol_admin"}); err != nil {
+		t.Fatal(err)
+	}
+	rm := newFakeRoleManager(t, fake)
+
+	if _, err := rm.GetRoles("alice@example.com", "acme"); err == nil {
+		t.Error("domains should be rejected while organizations are disabled")
+	}
+	rm.EnableOrganizations(true)
+	rm.AddDomainAlias("acme-corp", "acme")
+
+	if roles, _ := rm.GetRoles("alice@example.com", "acme-corp"); !util.ArrayEquals(roles, []string{"admin"}) {
+		t.Errorf("alice@example.com in acme: %s, supposed to be [admin]", roles)
+	}
+	if users, _ := rm.GetUsers("admin", "acme"); !util.ArrayEquals(users, []string{"alice@example.com"}) {
+		t.Errorf("admin in acme: %s, supposed to be [alice@example.com]", users)
+	}
+	if ok, _ := rm.HasLink("alice@example.com", "admin", "acme"); !ok {
+		t.Error("alice@example.com < admin in acme: false, supposed to be true")
+	}
+	if ok, _ := rm.HasLink("bob@example.com", "admin", "org_acme"); ok {
+		t.Error("bob@example.com < admin in org_acme: true, supposed to be false")
+	}
+	if _, err := rm.GetRoles("alice@example.com", "initech"); err == nil {
+		t.Error("unknown organizations should be rejected")
+	}
+
+	if err := rm.AddLink("bob@example.com", "admin", "acme"); err != nil {
+		t.Fatal(err)
+	}
+	if roles, _ := fake.OrganizationMemberRoles(context.Background(), "org_acme", "auth0|bob", ListOptions{}); len(roles.Roles) != 1 || roles.Roles[0].GetID() != "rol_admin" {
+		t.Errorf("roles of bob@example.com in acme: %v, supposed to be rol_admin", roles)
+	}
+	if err := rm.AddLink("editor", "admin", "acme"); err == nil {
+		t.Error("links between roles should not support domains")
+	}
+
+	if domains, _ := rm.GetDomains("alice@example.com"); !util.ArrayEquals(domains, []string{"acme"}) {
+		t.Errorf("domains of alice@example.com: %s, supposed to be [acme]", domains)
+	}
+	if domains, _ := rm.GetAllDomains(); !util.ArrayEquals(domains, []string{"acme", "globex"}) {
+		t.Errorf("all domains: %s, supposed to be [acme globex]", domains)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/auth0/go-auth0/management"
+)
+
+// Permission is an Auth0 permission, a scope on a resource server.
+type Permission struct {
+	// ResourceServer is the identifier of the resource server, e.g.
+	// "https://api.example.com".
+	ResourceServer string `json:"resource_server"`
+	// Name is the name of the permission, e.g. "read:messages".
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// String returns the name of the permission used by HasLink with
+// permission links, "<resource server>:<permission>", e.g.
+// "https://api.example.com:read:messages".
+func (p Permission) String() string {
+	return p.ResourceServer + ":" + p.Name
+}
+
+// EnablePermissionLinks makes HasLink(name, "<resource server>:<permission>")
+// check whether the user or role has the Auth0 permission, see
+// GetPermissionsForUser, so that policies can check Auth0 permissions
+// instead of duplicating them. Role names take precedence over permissions.
+//
+// Only names whose resource server is a URL, e.g.
+// "https://api.example.com:read:messages", or one of the identifiers set by
+// SetPermissionResourceServers are permissions, so that the users of the
+// other policy rules do not cost any call.
+func (rm *RoleManager) EnablePermissionLinks(enable bool) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.permissionLinks = enable
+}
+
+// SetPermissionResourceServers sets the identifiers of the resource servers
+// that are not URLs, e.g. "messages-api", for the permissions checked by
+// HasLink, see EnablePermissionLinks.
+func (rm *RoleManager) SetPermissionResourceServers(identifiers ...string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.resourceServers = map[string]bool{}
+	for _, identifier := range identifiers {
+		rm.resourceServers[identifier] = true
+	}
+}
+
+// permissionLink determines whether HasLink checks name as a permission:
+// permission links are enabled, and name is "<resource server>:<permission>"
+// for a resource server identified by a URL or set by
+// SetPermissionResourceServers.
+func (rm *RoleManager) permissionLink(name string) bool {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	if !rm.permissionLinks {
+		return false
+	}
+	for i := 1; i < len(name)-1; i++ {
+		if name[i] != ':' {
+			continue
+		}
+		resourceServer := name[:i]
+		if rm.resourceServers[resourceServer] {
+			return true
+		}
+		if u, err := url.Parse(resourceServer); err == nil && u.Scheme != "" && u.Host != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPermissionsForRole gets the permissions of an Auth0 role, not
+// including the permissions of the roles it inherits through the local role
+// hierarchy.
+func (rm *RoleManager) GetPermissionsForRole(name string) ([]Permission, error) {
+	return rm.GetPermissionsForRoleCtx(context.Background(), name)
+}
+
+// GetPermissionsForRoleCtx is like GetPermissionsForRole, with ctx bounding the Management API calls.
+func (rm *RoleManager) GetPermissionsForRoleCtx(ctx context.Context, name string) ([]Permission, error) {
+	rm.refreshIfStale()
+
+	roleID, err := rm.roleID(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return rm.getAuth0Permissions(ctx, rm.api.RolePermissions, roleID)
+}
+
+// GetPermissionsForUser gets the effective permissions of a user: the
+// permissions assigned to the user directly, and the permissions of all the
+// roles the user inherits, see GetRoleInfos.
+// domain is an Auth0 Organization, see EnableOrganizations.
+func (rm *RoleManager) GetPermissionsForUser(name string, domain ...string) ([]Permission, error) {
+	return rm.GetPermissionsForUserCtx(context.Background(), name, domain...)
+}
+
+// GetPermissionsForUserCtx is like GetPermissionsForUser, with ctx bounding the Management API calls.
+func (rm *RoleManager) GetPermissionsForUserCtx(ctx context.Context, name string, domain ...string) ([]Permission, error) {
+	roles, err := rm.GetRoleInfosCtx(ctx, name, domain...)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := rm.userID(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := rm.getAuth0Permissions(ctx, rm.api.UserPermissions, userID)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs := []string{}
+	for _, role := range roles {
+		if role.ID != "" {
+			roleIDs = append(roleIDs, role.ID)
+		}
+	}
+	return rm.addRolePermissions(ctx, res, roleIDs)
+}
+
+// hasPermission determines whether a user or role has a permission, given
+// as by Permission.String.
+func (rm *RoleManager) hasPermission(ctx context.Context, name string, permission string, domain ...string) (bool, error) {
+	var permissions []Permission
+	if rm.isRole(name) {
+		rm.mu.RLock()
+		roleIDs := []string{rm.nameToIDMap[name]}
+		for _, ancestor := range rm.hierarchy.ancestorsWithin(name, rm.hierarchyLevels()) {
+			if id, ok := rm.nameToIDMap[ancestor]; ok && rm.roles[ancestor] {
+				roleIDs = append(roleIDs, id)
+			}
+		}
+		rm.mu.RUnlock()
+
+		var err error
+		permissions, err = rm.addRolePermissions(ctx, []Permission{}, roleIDs)
+		if err != nil {
+			return false, err
+		}
+	} else {
+		var err error
+		permissions, err = rm.GetPermissionsForUserCtx(ctx, name, domain...)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	for _, p := range permissions {
+		if p.String() == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// addRolePermissions adds the permissions of roles to res, skipping the
+// ones already in it.
+func (rm *RoleManager) addRolePermissions(ctx context.Context, res []Permission, roleIDs []string) ([]Permission, error) {
+	seen := map[string]bool{}
+	for _, p := range res {
+		seen[p.String()] = true
+	}
+	for _, roleID := range roleIDs {
+		permissions, err := rm.getAuth0Permissions(ctx, rm.api.RolePermissions, roleID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range permissions {
+			if !seen[p.String()] {
+				seen[p.String()] = true
+				res = append(res, p)
+			}
+		}
+	}
+	return res, nil
+}
+
+// getAuth0Permissions gets all the permissions listed by f for the user or
+// role id.
+func (rm *RoleManager) getAuth0Permissions(ctx context.Context, f func(context.Context, string, ListOptions) (*management.PermissionList, error), id string) ([]Permission, error) {
+	res := []Permission{}
+
+	list := func(ctx context.Context, opts ListOptions) (*management.PermissionList, error) {
+		return f(ctx, id, opts)
+	}
+	for p := 0; ; p++ {
+		permissions, _, err := pager(ctx, rm, list, ListOptions{}, p)
+		if err != nil {
+			return nil, err
+		}
+		for _, permission := range permissions.Permissions {
+			res = append(res, Permission{
+				ResourceServer: permission.GetResourceServerIdentifier(),
+				Name:           permission.GetName(),
+				Description:    permission.GetDescription(),
+			})
+		}
+		if !permissions.HasNext() {
+			break
+		}
+	}
+	return res, nil
+}
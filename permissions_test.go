@@ -0,0 +1,108 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"testing"
+
+	"github.com/auth0/go-auth0"
+	"github.com/auth0/go-auth0/management"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func permission(name string) *management.Permission {
+	return &management.Permission{
+		ResourceServerIdentifier: auth0.String("https://api.example.com"),
+		Name:                     auth0.String(name),
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	fake := auth0test.New()
+	addRole(fake, "rol_admin", "admin")
+	addRole(fake, "rol_editor", "editor")
+	fake.AddRolePermissions("rol_editor", permission("read:profile"), permission("write:posts"))
+	fake.AddRolePermissions("rol_admin", permission("delete:posts"))
+	rm := newFakeRoleManager(t, fake, WithPermissionLinks())
+
+	// alice is created after the load.
+	addUser(fake, "auth0|alice", "alice@example.com")
+	assignRoles(t, fake, "auth0|alice", "rol_editor")
+	fake.AddUserPermissions("auth0|alice", permission("read:profile"))
+
+	if err := rm.AddLink("admin", "editor"); err != nil {
+		t.Fatal(err)
+	}
+
+	permissions, err := rm.GetPermissionsForRole("editor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(permissions) != 2 || permissions[1].String() != "https://api.example.com:write:posts" {
+		t.Errorf("permissions of editor: %v, supposed to be read:profile and write:posts", permissions)
+	}
+
+	// Permissions assigned directly and through roles are merged.
+	permissions, err = rm.GetPermissionsForUser("alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(permissions) != 2 {
+		t.Errorf("permissions of alice@example.com: %v, supposed to be read:profile and write:posts", permissions)
+	}
+
+	testRole(t, rm, "alice@example.com", "https://api.example.com:write:posts", true)
+	testRole(t, rm, "alice@example.com", "https://api.example.com:delete:posts", false)
+	testRole(t, rm, "alice@example.com", "editor", true)
+	testRole(t, rm, "admin", "https://api.example.com:write:posts", true)
+	testRole(t, rm, "editor", "https://api.example.com:delete:posts", false)
+}
+
+func TestPermissionLinkNames(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	addRole(fake, "rol_editor", "editor")
+	assignRoles(t, fake, "auth0|alice", "rol_editor")
+	fake.AddRolePermissions("rol_editor", &management.Permission{
+		ResourceServerIdentifier: auth0.String("messages-api"),
+		Name:                     auth0.String("read:messages"),
+	})
+	rm := newFakeRoleManager(t, fake, WithPermissionLinks("messages-api"))
+
+	// The users of other policy rules are not checked as permissions.
+	calls := len(fake.Calls())
+	testRole(t, rm, "alice@example.com", "bob@example.com", false)
+	testRole(t, rm, "alice@example.com", "messages:read", false)
+	for _, c := range fake.Calls()[calls:] {
+		if c.Method == "UserPermissions" || c.Method == "RolePermissions" {
+			t.Errorf("call: %+v, supposed to check no permission", c)
+		}
+	}
+
+	testRole(t, rm, "alice@example.com", "messages-api:read:messages", true)
+	for name, ok := range map[string]bool{
+		"https://api.example.com:read:messages": true,
+		"messages-api:read:messages":            true,
+		"alice@example.com":                     false,
+		"other-api:read:messages":               false,
+		"https:":                                false,
+		"https://api.example.com:":              false,
+	} {
+		if rm.permissionLink(name) != ok {
+			t.Errorf("%s: %t, supposed to be %t", name, !ok, ok)
+		}
+	}
+}
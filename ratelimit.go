@@ -0,0 +1,145 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultMaxRetries is the number of times a rate limited request is
+	// retried.
+	defaultMaxRetries = 5
+	// defaultMaxRetryWait is the longest wait before retrying a rate
+	// limited request.
+	defaultMaxRetryWait = 30 * time.Second
+	// retryBaseDelay is the wait before the first retry when Auth0 does not
+	// say when the rate limit resets, doubled on every retry.
+	retryBaseDelay = 250 * time.Millisecond
+)
+
+// RateLimitError is returned when a Management API request is still rate
+// limited after the configured number of retries, see WithMaxRetries.
+// Reset is when Auth0 expects the rate limit to reset, if it said so.
+type RateLimitError struct {
+	Retries int
+	Reset   time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("error: Management API rate limit exceeded after %d retries", e.Retries)
+}
+
+// retryTransport retries the requests rejected by the Management API with
+// 429 Too Many Requests. It waits until the X-RateLimit-Reset time if
+// given, and at least a jittered exponential backoff, but never longer than
+// maxWait.
+//
+// go-auth0 retries 429 responses indefinitely itself, so once maxRetries is
+// reached a *RateLimitError is returned instead of the response.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	maxWait    time.Duration
+	// observe is called with every request once it is done, if not nil.
+	observe func(APICall)
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	res, rateLimited, err := t.roundTrip(req)
+	if t.observe != nil {
+		call := APICall{
+			Method:      req.Method,
+			Path:        req.URL.Path,
+			RateLimited: rateLimited,
+			Duration:    time.Since(start),
+			Err:         err,
+		}
+		if res != nil {
+			call.StatusCode = res.StatusCode
+		}
+		t.observe(call)
+	}
+	return res, err
+}
+
+// roundTrip does the request, retrying it while rate limited. It returns the
+// number of 429 responses received.
+func (t *retryTransport) roundTrip(req *http.Request) (*http.Response, int, error) {
+	for retry := 0; ; retry++ {
+		res, err := t.base.RoundTrip(req)
+		if err != nil || res.StatusCode != http.StatusTooManyRequests {
+			return res, retry, err
+		}
+		reset := rateLimitReset(res.Header)
+		_, _ = io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+
+		if retry >= t.maxRetries || (req.Body != nil && req.GetBody == nil) {
+			return nil, retry + 1, &RateLimitError{Retries: retry, Reset: reset}
+		}
+
+		timer := time.NewTimer(t.wait(retry, reset))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, retry + 1, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, retry + 1, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// wait returns how long to wait before the given retry.
+func (t *retryTransport) wait(retry int, reset time.Time) time.Duration {
+	backoff := retryBaseDelay << retry
+	if backoff <= 0 || backoff > t.maxWait {
+		backoff = t.maxWait
+	}
+	// Jitter over the upper half of the backoff keeps concurrent callers
+	// apart.
+	d := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+	if untilReset := time.Until(reset); untilReset > d {
+		d = untilReset
+	}
+	if d > t.maxWait {
+		d = t.maxWait
+	}
+	return d
+}
+
+// rateLimitReset returns the time given by the X-RateLimit-Reset header,
+// in seconds since the epoch, or the zero time if missing.
+func rateLimitReset(header http.Header) time.Time {
+	sec, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-auth0/management"
+)
+
+func TestRateLimitRetries(t *testing.T) {
+	var requests, limited int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/roles", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.AddInt32(&limited, -1) >= 0 {
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"roles": [{"id": "rol_admin", "name": "admin"}], "start": 0, "limit": 100, "total": 1}`)
+	})
+	rm := newTestRoleManager(t, mux, WithMaxRetries(2), WithMaxRetryWait(10*time.Millisecond))
+
+	listRoles := func(m *management.Management) error {
+		_, err := m.Role.List()
+		return err
+	}
+
+	// Two rate limited responses are retried.
+	atomic.StoreInt32(&limited, 2)
+	if err := rm.Do(listRoles); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("requests: %d, supposed to be 3", n)
+	}
+
+	// A third one fails the call instead of retrying forever.
+	atomic.StoreInt32(&requests, 0)
+	atomic.StoreInt32(&limited, 3)
+	err := rm.Do(listRoles)
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("error: %v, supposed to be a *RateLimitError", err)
+	}
+	if rateLimitErr.Retries != 2 {
+		t.Errorf("retries: %d, supposed to be 2", rateLimitErr.Retries)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("requests: %d, supposed to be 3", n)
+	}
+}
+
+func TestRetryWait(t *testing.T) {
+	tr := &retryTransport{maxWait: time.Second}
+	for retry := 0; retry < 8; retry++ {
+		if d := tr.wait(retry, time.Time{}); d <= 0 || d > time.Second {
+			t.Errorf("wait before retry %d: %s, supposed to be in (0, 1s]", retry, d)
+		}
+	}
+	if d := tr.wait(0, time.Now().Add(time.Minute)); d != time.Second {
+		t.Errorf("wait until a reset in a minute: %s, supposed to be capped to 1s", d)
+	}
+}
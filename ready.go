@@ -0,0 +1,51 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+// Ready returns a channel closed once the (ID, name) mapping has been
+// loaded successfully. Services can wait on it before reporting themselves
+// ready. A failed load leaves it open, until a later Load succeeds.
+func (rm *RoleManager) Ready() <-chan struct{} {
+	return rm.ready
+}
+
+// IsReady determines whether the mapping has been loaded successfully, and
+// returns the error of the last load if it failed.
+func (rm *RoleManager) IsReady() (bool, error) {
+	rm.mu.RLock()
+	err := rm.loadErr
+	rm.mu.RUnlock()
+
+	select {
+	case <-rm.ready:
+		return true, err
+	default:
+		return false, err
+	}
+}
+
+// markReady records the outcome of a load, closing the Ready channel on the
+// first success.
+func (rm *RoleManager) markReady(err error) {
+	rm.mu.Lock()
+	rm.loadErr = err
+	rm.mu.Unlock()
+
+	if err == nil {
+		rm.readyOnce.Do(func() {
+			close(rm.ready)
+		})
+	}
+}
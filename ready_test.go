@@ -0,0 +1,83 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestReady(t *testing.T) {
+	rm := &RoleManager{ready: make(chan struct{})}
+
+	if ready, _ := rm.IsReady(); ready {
+		t.Error("role manager should not be ready before the initial load")
+	}
+
+	// A failed load does not make the role manager ready.
+	loadErr := errors.New("load failed")
+	rm.markReady(loadErr)
+	if ready, err := rm.IsReady(); ready || err != loadErr {
+		t.Errorf("ready: %t, %v, supposed to be false, %v", ready, err, loadErr)
+	}
+	select {
+	case <-rm.Ready():
+		t.Error("Ready should not be closed by a failed load")
+	default:
+	}
+
+	rm.markReady(nil)
+	<-rm.Ready()
+	if ready, err := rm.IsReady(); !ready || err != nil {
+		t.Errorf("ready: %t, %v, supposed to be true, <nil>", ready, err)
+	}
+
+	// Later failures are reported, the role manager staying ready.
+	rm.markReady(loadErr)
+	if ready, err := rm.IsReady(); !ready || err != loadErr {
+		t.Errorf("ready: %t, %v, supposed to be true, %v", ready, err, loadErr)
+	}
+}
+
+func TestReadyAfterFailedLoad(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	rm, err := newRoleManager("", "", "", WithManagementAPI(fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake.Intercept(func(c auth0test.Call) error {
+		return &auth0test.Error{StatusCode: http.StatusInternalServerError, Message: "failed"}
+	})
+	if err := rm.Load(); err == nil {
+		t.Fatal("the failing user listing should fail Load")
+	}
+	if ready, err := rm.IsReady(); ready || err == nil {
+		t.Errorf("ready: %t, %v, supposed to be false with the load error", ready, err)
+	}
+
+	fake.Intercept(nil)
+	if err := rm.Load(); err != nil {
+		t.Fatal(err)
+	}
+	<-rm.Ready()
+	if ready, err := rm.IsReady(); !ready || err != nil {
+		t.Errorf("ready: %t, %v, supposed to be true, <nil>", ready, err)
+	}
+}
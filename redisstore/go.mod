@@ -0,0 +1,26 @@
+module github.com/olvesh/auth0-role-manager/v2/redisstore
+
+go 1.19
+
+require (
+	github.com/alicebob/miniredis/v2 v2.39.0
+	github.com/auth0/go-auth0 v0.12.0
+	github.com/olvesh/auth0-role-manager/v2 v2.0.0
+	github.com/redis/go-redis/v9 v9.0.5
+)
+
+require (
+	github.com/PuerkitoBio/rehttp v1.1.0 // indirect
+	github.com/casbin/casbin/v2 v2.135.0 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/golang/protobuf v1.5.2 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	golang.org/x/net v0.1.0 // indirect
+	golang.org/x/oauth2 v0.1.0 // indirect
+	golang.org/x/sync v0.1.0 // indirect
+	google.golang.org/appengine v1.6.7 // indirect
+	google.golang.org/protobuf v1.28.0 // indirect
+)
+
+replace github.com/olvesh/auth0-role-manager/v2 => ../
@@ -0,0 +1,98 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redisstore provides a HierarchyStore keeping the local role
+// hierarchy of the role manager in a Redis set:
+//
+//	store := redisstore.New(redis.NewClient(&redis.Options{Addr: "localhost:6379"}), "role_hierarchy")
+//	if err := rm.SetHierarchyStore(store); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// It is a separate module, so that the role manager does not depend on the
+// Redis client.
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+
+	auth0rolemanager "github.com/olvesh/auth0-role-manager/v2"
+)
+
+var _ auth0rolemanager.HierarchyStore = (*Store)(nil)
+
+// Store is a HierarchyStore keeping the edges as members of a Redis set.
+type Store struct {
+	client redis.UniversalClient
+	key    string
+}
+
+// New is the constructor of a Store. key is the name of the Redis set
+// holding the edges.
+func New(client redis.UniversalClient, key string) *Store {
+	return &Store{client: client, key: key}
+}
+
+// LoadEdges returns all the stored edges.
+func (s *Store) LoadEdges() ([]auth0rolemanager.Edge, error) {
+	return s.LoadEdgesCtx(context.Background())
+}
+
+// LoadEdgesCtx is like LoadEdges, with ctx bounding the command.
+func (s *Store) LoadEdgesCtx(ctx context.Context) ([]auth0rolemanager.Edge, error) {
+	members, err := s.client.SMembers(ctx, s.key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	edges := make([]auth0rolemanager.Edge, 0, len(members))
+	for _, member := range members {
+		var pair [2]string
+		if err := json.Unmarshal([]byte(member), &pair); err != nil {
+			return nil, err
+		}
+		edges = append(edges, auth0rolemanager.Edge{Role: pair[0], Parent: pair[1]})
+	}
+	return edges, nil
+}
+
+// AddEdge stores a single edge.
+func (s *Store) AddEdge(edge auth0rolemanager.Edge) error {
+	return s.AddEdgeCtx(context.Background(), edge)
+}
+
+// AddEdgeCtx is like AddEdge, with ctx bounding the command.
+func (s *Store) AddEdgeCtx(ctx context.Context, edge auth0rolemanager.Edge) error {
+	return s.client.SAdd(ctx, s.key, member(edge)).Err()
+}
+
+// RemoveEdge deletes a single edge.
+func (s *Store) RemoveEdge(edge auth0rolemanager.Edge) error {
+	return s.RemoveEdgeCtx(context.Background(), edge)
+}
+
+// RemoveEdgeCtx is like RemoveEdge, with ctx bounding the command.
+func (s *Store) RemoveEdgeCtx(ctx context.Context, edge auth0rolemanager.Edge) error {
+	return s.client.SRem(ctx, s.key, member(edge)).Err()
+}
+
+// member encodes an edge as a JSON pair, so role names may contain any
+// character.
+func member(edge auth0rolemanager.Edge) string {
+	b, _ := json.Marshal([2]string{edge.Role, edge.Parent})
+	return string(b)
+}
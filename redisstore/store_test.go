@@ -0,0 +1,95 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redisstore
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/auth0/go-auth0"
+	"github.com/auth0/go-auth0/management"
+	"github.com/redis/go-redis/v9"
+
+	auth0rolemanager "github.com/olvesh/auth0-role-manager/v2"
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	s := New(redis.NewClient(&redis.Options{Addr: server.Addr()}), "role_hierarchy")
+
+	edges := []auth0rolemanager.Edge{
+		{Role: "admin", Parent: "editor"},
+		{Role: "editor", Parent: "viewer, reader"},
+		{Role: "admin", Parent: "editor"},
+	}
+	for _, edge := range edges {
+		if err := s.AddEdge(edge); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.RemoveEdge(auth0rolemanager.Edge{Role: "admin", Parent: "editor"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveEdge(auth0rolemanager.Edge{Role: "admin", Parent: "editor"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddEdge(auth0rolemanager.Edge{Role: "admin", Parent: "auditor"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.LoadEdges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].Role < got[j].Role })
+	want := []auth0rolemanager.Edge{
+		{Role: "admin", Parent: "auditor"},
+		{Role: "editor", Parent: "viewer, reader"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("edges: %v, supposed to be %v", got, want)
+	}
+
+	// The hierarchy of a role manager is restored from the store.
+	fake := auth0test.New()
+	fake.AddRole(&management.Role{Name: auth0.String("admin")})
+	fake.AddRole(&management.Role{Name: auth0.String("auditor")})
+	rm, err := auth0rolemanager.NewRoleManagerWithOptions("", "", "", auth0rolemanager.WithManagementAPI(fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.(*auth0rolemanager.RoleManager).SetHierarchyStore(s); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := rm.HasLink("admin", "auditor"); err != nil || !ok {
+		t.Errorf("admin, auditor: %t, %v, supposed to be linked", ok, err)
+	}
+}
+
+func TestStoreError(t *testing.T) {
+	server := miniredis.RunT(t)
+	s := New(redis.NewClient(&redis.Options{Addr: server.Addr()}), "role_hierarchy")
+	server.Close()
+
+	if _, err := s.LoadEdges(); err == nil {
+		t.Error("LoadEdges should fail without a server")
+	}
+	if err := s.AddEdge(auth0rolemanager.Edge{Role: "admin", Parent: "editor"}); err == nil {
+		t.Error("AddEdge should fail without a server")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"time"
+)
+
+// Refresh reloads the (ID, name) mapping of users and roles from Auth0,
+// e.g. after provisioning changes.
+func (rm *RoleManager) Refresh() error {
+	return rm.RefreshCtx(context.Background())
+}
+
+// RefreshCtx is like Refresh, with ctx bounding the Management API calls.
+func (rm *RoleManager) RefreshCtx(ctx context.Context) error {
+	return rm.LoadCtx(ctx)
+}
+
+// InvalidateUser drops a user from the (ID, name) mapping, so that it is
+// looked up again in Auth0 the next time it is used.
+func (rm *RoleManager) InvalidateUser(name string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	id, ok := rm.nameToIDMap[name]
+	if !ok || rm.roles[name] {
+		return
+	}
+	delete(rm.nameToIDMap, name)
+	delete(rm.idToNameMap, id)
+	delete(rm.profiles, id)
+}
+
+// Close stops the background refreshes of the mapping, if any, and waits for
+// a refresh in progress to be canceled.
+func (rm *RoleManager) Close() error {
+	rm.stopOnce.Do(func() {
+		close(rm.stop)
+	})
+	rm.refreshes.Wait()
+	return nil
+}
+
+// refreshIfStale reloads the mapping in the background if it is older than
+// the mapping TTL, the stale mapping being used meanwhile. The reload is not
+// bound by the context of the caller that happened to notice it, but is
+// canceled by Close. If it fails, the error is logged and the reload is not
+// tried again before another mapping TTL, as each one can take several
+// retries.
+func (rm *RoleManager) refreshIfStale() {
+	if rm.mappingTTL <= 0 {
+		return
+	}
+	now := rm.now()
+	rm.mu.RLock()
+	loadedAt, failedAt := rm.loadedAt, rm.refreshFailedAt
+	rm.mu.RUnlock()
+	if loadedAt.IsZero() || now.Sub(loadedAt) < rm.mappingTTL || now.Sub(failedAt) < rm.mappingTTL {
+		return
+	}
+	if !rm.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	rm.refreshes.Add(1)
+	go func() {
+		defer rm.refreshes.Done()
+		defer rm.refreshing.Store(false)
+
+		ctx, cancel := rm.stopContext()
+		defer cancel()
+
+		if err := rm.loadMapping(ctx); err != nil {
+			rm.logf(LevelError, "Error refreshing stale mapping: '%v'", err)
+			rm.mu.Lock()
+			rm.refreshFailedAt = rm.now()
+			rm.mu.Unlock()
+		}
+	}()
+}
+
+// refreshLoop reloads the mapping every refresh interval until Close, which
+// cancels a reload in progress. rm.refreshes must be incremented for it.
+func (rm *RoleManager) refreshLoop() {
+	defer rm.refreshes.Done()
+
+	ctx, cancel := rm.stopContext()
+	defer cancel()
+	ticker := time.NewTicker(rm.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := rm.RefreshCtx(ctx); err != nil {
+				rm.logf(LevelError, "Error refreshing mapping: '%v'", err)
+			}
+		}
+	}
+}
+
+// stopContext returns a context canceled by Close, for the background
+// refreshes.
+func (rm *RoleManager) stopContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-rm.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
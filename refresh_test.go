@@ -0,0 +1,202 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestRefresh(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	addRole(fake, "rol_admin", "admin")
+	addRole(fake, "rol_editor", "editor")
+	rm := newFakeRoleManager(t, fake, WithMappingTTL(time.Minute))
+	now := time.Now()
+	rm.now = func() time.Time { return now }
+	if rm.nameToIDMap["alice@example.com"] != "auth0|alice" {
+		t.Fatal("alice@example.com should have been loaded")
+	}
+
+	// The mapping is reloaded in the background once stale, dropping
+	// deleted users.
+	fake.DeleteUser("auth0|alice")
+	addUser(fake, "auth0|bob", "bob@example.com")
+	_, _ = rm.GetRoles("bob@example.com")
+	rm.refreshes.Wait()
+	if _, ok := rm.nameToIDMap["alice@example.com"]; !ok {
+		t.Error("alice@example.com should be kept until the mapping is stale")
+	}
+	now = now.Add(2 * time.Minute)
+	_, _ = rm.GetRoles("bob@example.com")
+	rm.refreshes.Wait()
+	if _, ok := rm.nameToIDMap["alice@example.com"]; ok {
+		t.Error("alice@example.com should have been dropped on reload")
+	}
+
+	// Invalidated users are looked up again.
+	fake.DeleteUser("auth0|bob")
+	addUser(fake, "auth0|bob2", "bob@example.com")
+	rm.InvalidateUser("bob@example.com")
+	rm.InvalidateUser("admin")
+	if !rm.roles["admin"] {
+		t.Error("roles should not be invalidated as users")
+	}
+	_, _ = rm.GetRoles("bob@example.com")
+	if rm.nameToIDMap["bob@example.com"] != "auth0|bob2" {
+		t.Errorf("bob@example.com: %s, supposed to be auth0|bob2", rm.nameToIDMap["bob@example.com"])
+	}
+
+	// Clear reloads the mapping and resets the hierarchy.
+	if err := rm.AddLink("admin", "editor"); err != nil {
+		t.Fatal(err)
+	}
+	addUser(fake, "auth0|carol", "carol@example.com")
+	if err := rm.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rm.nameToIDMap["carol@example.com"]; !ok {
+		t.Error("carol@example.com should have been loaded on Clear")
+	}
+	testRole(t, rm, "admin", "editor", false)
+}
+
+func TestStaleRefresh(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	rm := newFakeRoleManager(t, fake, WithMappingTTL(time.Minute))
+	now := time.Now()
+	rm.now = func() time.Time { return now }
+
+	// The stale mapping is served, the reload not being bound by the
+	// context of the caller.
+	release := make(chan struct{})
+	fake.Intercept(func(c auth0test.Call) error {
+		if c.Method == "ListUsers" {
+			<-release
+		}
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	now = now.Add(2 * time.Minute)
+	if _, err := rm.GetRolesCtx(ctx, "alice@example.com"); err != nil {
+		t.Error(err)
+	}
+	cancel()
+	close(release)
+	rm.refreshes.Wait()
+	if loads := rm.Stats(); loads.MappingLoads != 2 || loads.MappingLoadErrors != 0 {
+		t.Errorf("stats: %+v, supposed to count 2 loads and no error", loads)
+	}
+
+	// A failed reload is not tried again before another mapping TTL.
+	fake.Intercept(func(c auth0test.Call) error {
+		return &auth0test.Error{StatusCode: http.StatusInternalServerError, Message: "failed"}
+	})
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 3; i++ {
+		_, _ = rm.GetRoles("alice@example.com")
+		rm.refreshes.Wait()
+	}
+	if loads := rm.Stats(); loads.MappingLoads != 3 || loads.MappingLoadErrors != 1 {
+		t.Errorf("stats: %+v, supposed to count 3 loads and 1 error", loads)
+	}
+	now = now.Add(time.Minute)
+	_, _ = rm.GetRoles("alice@example.com")
+	rm.refreshes.Wait()
+	if loads := rm.Stats(); loads.MappingLoads != 4 {
+		t.Errorf("stats: %+v, supposed to count 4 loads", loads)
+	}
+
+	// Close cancels a reload in progress.
+	started, release := make(chan struct{}), make(chan struct{})
+	fake.Intercept(func(c auth0test.Call) error {
+		if c.Method == "ListUsers" {
+			close(started)
+			<-release
+		}
+		return nil
+	})
+	now = now.Add(2 * time.Minute)
+	_, _ = rm.GetRoles("alice@example.com")
+	<-started
+	testCloseWaits(t, rm, release)
+}
+
+func TestRefreshLoop(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	rm, err := NewRoleManagerWithOptions("", "", "",
+		WithManagementAPI(fake),
+		WithRefreshInterval(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Close cancels a periodic reload in progress.
+	var once sync.Once
+	started, release := make(chan struct{}), make(chan struct{})
+	fake.Intercept(func(c auth0test.Call) error {
+		if c.Method == "ListUsers" {
+			once.Do(func() { close(started) })
+			<-release
+		}
+		return nil
+	})
+	<-started
+	testCloseWaits(t, rm.(*RoleManager), release)
+
+	// The reloads are canceled once closed.
+	ctx, cancel := rm.(*RoleManager).stopContext()
+	defer cancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("the context of the reloads should be canceled by Close")
+	}
+}
+
+// testCloseWaits closes rm while a reload is blocked until release is
+// closed, and checks that Close waits for the reload to end.
+func testCloseWaits(t *testing.T, rm *RoleManager, release chan struct{}) {
+	t.Helper()
+	loads := rm.Stats().MappingLoads
+	done := make(chan struct{})
+	go func() {
+		_ = rm.Close()
+		close(done)
+	}()
+	<-rm.stop
+	select {
+	case <-done:
+		t.Fatal("Close should wait for the reload in progress")
+	default:
+	}
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close should return once the reload is done")
+	}
+	if stats := rm.Stats(); stats.MappingLoads != loads+1 {
+		t.Errorf("stats: %+v, supposed to count the reload before Close returns", stats)
+	}
+}
@@ -15,21 +15,114 @@
 package auth0rolemanager
 
 import (
+	"context"
 	"errors"
-
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/auth0/go-auth0"
 	"github.com/auth0/go-auth0/management"
-	"github.com/casbin/casbin/log"
-	"github.com/casbin/casbin/rbac"
+	"github.com/casbin/casbin/v2/log"
+	"github.com/casbin/casbin/v2/rbac"
+	"golang.org/x/oauth2"
 )
 
 type RoleManager struct {
 	clientID     string
 	clientSecret string
+	token        string
+	tokenSource  oauth2.TokenSource
 	tenant       string
+	domain       string
+
+	// mu guards the mapping, the local role hierarchy and the settings
+	// changed by the Set* methods. It is never held during Management API
+	// calls.
+	mu sync.RWMutex
 
 	nameToIDMap map[string]string
 	idToNameMap map[string]string
-
+	roles       map[string]bool
+	profiles    map[string]*management.User
+	auth0Roles  map[string]*management.Role
+
+	identityAttribute string
+	identityResolver  IdentityResolver
+
+	roleNameMapping   map[string]string
+	roleNameTransform RoleNameTransform
+	roleExclusions    []*regexp.Regexp
+
+	accountStateRoles bool
+	metadataRules     []MetadataRule
+
+	syntheticRoleProvider SyntheticRoleProvider
+
+	fields      []string
+	query       string
+	connections []string
+
+	hierarchy         *roleHierarchy
+	store             HierarchyStore
+	maxHierarchyLevel int
+
+	domainAliases  map[string]string
+	domainResolver DomainResolver
+
+	matchingFunc       rbac.MatchingFunc
+	domainMatchingFunc rbac.MatchingFunc
+
+	organizations bool
+	orgIDs        map[string]string
+
+	permissionLinks bool
+	resourceServers map[string]bool
+	changeHandler   func(Change)
+
+	ready     chan struct{}
+	readyOnce sync.Once
+	loadErr   error
+
+	mappingTTL      time.Duration
+	refreshInterval time.Duration
+	loadedAt        time.Time
+	refreshFailedAt time.Time
+	refreshing      atomic.Bool
+	refreshes       sync.WaitGroup
+	now             func() time.Time
+	stop            chan struct{}
+	stopOnce        sync.Once
+
+	pageSize        int
+	loadConcurrency int
+	loadProgress    func(LoadProgress)
+	loadMu          sync.Mutex
+	pendingLoad     *loadState
+	preload         bool
+	httpClient      *http.Client
+	requestTimeout  time.Duration
+	maxRetries      int
+	maxRetryWait    time.Duration
+
+	// logMu guards the loggers. It is separate from mu, as messages are
+	// logged with mu held.
+	logMu         sync.RWMutex
+	logger        log.Logger
+	leveledLogger Logger
+	redactPII     bool
+	apiCallHook   func(APICall)
+	stats         stats
+
+	// misses are the users and roles recently not found in Auth0, nil if
+	// they are not remembered.
+	misses *missCache
+
+	api        ManagementAPI
 	mgmtClient *management.Management
 	//authzClient *auth0.Auth0
 }
@@ -38,99 +131,216 @@ type RoleManager struct {
 // clientID is the Client ID.
 // clientSecret is the Client Secret.
 // tenant is your tenant name. If your domain is: abc.auth0.com, then abc is your tenant name.
+//
+// Deprecated: NewRoleManager panics if the Management API client cannot be
+// created. Use NewRoleManagerWithOptions, which returns an error instead.
 func NewRoleManager(clientID string, clientSecret string, tenant string) rbac.RoleManager {
-	rm := RoleManager{}
+	rm, err := newRoleManager(clientID, clientSecret, tenant)
+	if err != nil {
+		panic(err)
+	}
+	rm.markReady(rm.loadMapping(context.Background()))
+
+	return rm
+}
+
+// NewRoleManagerWithOptions is the constructor of an Auth0 RoleManager
+// instance, configured by opts. Unless WithoutPreload is given, the (ID,
+// name) mapping is loaded before returning, and its error is returned.
+// clientID is the Client ID.
+// clientSecret is the Client Secret.
+// tenant is your tenant name. If your domain is: abc.auth0.com, then abc is your tenant name.
+// Regional tenants and custom domains are given by WithDomain or
+// WithIssuerURL instead.
+func NewRoleManagerWithOptions(clientID string, clientSecret string, tenant string, opts ...Option) (rbac.RoleManager, error) {
+	rm, err := newRoleManager(clientID, clientSecret, tenant, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if rm.preload {
+		if err := rm.Load(); err != nil {
+			return nil, err
+		}
+	}
+	if rm.refreshInterval > 0 {
+		rm.refreshes.Add(1)
+		go rm.refreshLoop()
+	}
+
+	return rm, nil
+}
+
+func newRoleManager(clientID string, clientSecret string, tenant string, opts ...Option) (*RoleManager, error) {
+	rm := &RoleManager{}
 	rm.clientID = clientID
 	rm.clientSecret = clientSecret
 	rm.tenant = tenant
+	rm.domain = tenantDomain(tenant)
 
 	rm.nameToIDMap = map[string]string{}
 	rm.idToNameMap = map[string]string{}
+	rm.roles = map[string]bool{}
+	rm.profiles = map[string]*management.User{}
+	rm.auth0Roles = map[string]*management.Role{}
+	rm.hierarchy = newRoleHierarchy()
+	rm.domainAliases = map[string]string{}
+	rm.orgIDs = map[string]string{}
+	rm.ready = make(chan struct{})
+	rm.logger = &log.DefaultLogger{}
+	rm.now = time.Now
+	rm.stop = make(chan struct{})
+
+	rm.pageSize = defaultPageSize
+	rm.loadConcurrency = defaultLoadConcurrency
+	rm.preload = true
+	rm.maxRetries = defaultMaxRetries
+	rm.maxRetryWait = defaultMaxRetryWait
+	rm.misses = newMissCache(defaultMissTTL, defaultMaxMisses)
+
+	for _, opt := range opts {
+		if err := opt(rm); err != nil {
+			return nil, err
+		}
+	}
 
-	err := rm.initialize()
-	if err != nil {
-		panic(err)
+	if err := rm.initialize(); err != nil {
+		return nil, err
 	}
-	rm.loadMapping()
+	return rm, nil
+}
 
-	return &rm
+// tenantDomain returns the domain of a tenant, abc.auth0.com for abc. Names
+// already holding a dot are taken as domains.
+func tenantDomain(tenant string) string {
+	if tenant == "" || strings.Contains(tenant, ".") {
+		return tenant
+	}
+	return tenant + ".auth0.com"
 }
 
 func (rm *RoleManager) initialize() error {
-	var err error
-	rm.mgmtClient, err = management.New(
-		rm.tenant,
-		management.WithClientCredentials(rm.clientID, rm.clientSecret),
-	)
+	if rm.api != nil {
+		return nil
+	}
+	if rm.domain == "" {
+		return errors.New("error: the Auth0 tenant or domain should be given")
+	}
 
-	return err
+	auth := management.WithClientCredentials(rm.clientID, rm.clientSecret)
+	switch {
+	case rm.token != "":
+		auth = management.WithStaticToken(rm.token)
+	case rm.tokenSource != nil:
+		auth = management.WithStaticToken(tokenSourcePlaceholder)
+	}
+
+	var err error
+	rm.mgmtClient, err = management.New(rm.domain, auth, management.WithClient(rm.newHTTPClient()))
+	if err != nil {
+		return err
+	}
+	rm.api = newManagementAPI(rm.mgmtClient, rm.newBaseHTTPClient())
+	return nil
 }
 
-func pager[T any](f func(...management.RequestOption) (T, error), pageNum int) (T, int, error) {
-	list, err := f(management.Page(pageNum), management.PerPage(100))
-	return list, pageNum + 1, err
+// newBaseHTTPClient returns an HTTP client set up by WithHTTPClient and
+// WithRequestTimeout, without the Management API credentials, e.g. to
+// download the users exports.
+func (rm *RoleManager) newBaseHTTPClient() *http.Client {
+	client := http.Client{}
+	if rm.httpClient != nil {
+		client = *rm.httpClient
+	}
+	if rm.requestTimeout > 0 {
+		client.Timeout = rm.requestTimeout
+	}
+	return &client
 }
 
-func (rm *RoleManager) loadMapping() {
-	log.LogPrintf("Loading (ID, name) mapping for users:")
+// newHTTPClient returns the HTTP client of the Management API calls, set up
+// by WithHTTPClient, WithRequestTimeout and WithTokenSource, and retrying the
+// rate limited requests.
+func (rm *RoleManager) newHTTPClient() *http.Client {
+	client := *rm.newBaseHTTPClient()
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if rm.tokenSource != nil {
+		base = &tokenTransport{base: base, source: rm.tokenSource}
+	}
+	client.Transport = &retryTransport{
+		base:       base,
+		maxRetries: rm.maxRetries,
+		maxWait:    rm.maxRetryWait,
+		observe:    rm.observeAPICall,
+	}
+	return &client
+}
 
-	usersFun := rm.mgmtClient.User.List
-	for p := 0; ; p++ {
-		users, _, err := pager(usersFun, p)
-		if err != nil {
-			log.LogPrintf("Error loading users: '%v'", err)
-			return
-		}
+// Load loads the (ID, name) mapping of users and roles from Auth0, and marks
+// the role manager as ready if it succeeds, see Ready. It is only needed
+// with WithoutPreload, or to try again after a failed load.
+func (rm *RoleManager) Load() error {
+	return rm.LoadCtx(context.Background())
+}
 
-		for _, user := range users.Users {
-			rm.nameToIDMap[*user.Email] = *user.ID
-			rm.idToNameMap[*user.ID] = *user.Email
-			log.LogPrintf("%s -> %s", user.ID, user.Email)
-		}
-		if !users.HasNext() {
-			break
-		}
-	}
+// LoadCtx is like Load, with ctx bounding the Management API calls.
+func (rm *RoleManager) LoadCtx(ctx context.Context) error {
+	err := rm.loadMapping(ctx)
+	rm.markReady(err)
+	return err
+}
 
-	log.LogPrintf("Loading (ID, name) mapping for roles:")
-	rolesFun := rm.mgmtClient.Role.List
-	for p := 0; ; p++ {
-		roles, _, err := pager(rolesFun, p)
-		if err != nil {
-			log.LogPrintf("Error loading roles: '%v'", err)
-			return
-		}
-		for _, group := range roles.Roles {
-			rm.nameToIDMap[*group.Name] = *group.ID
-			rm.idToNameMap[*group.ID] = *group.Name
-			log.LogPrintf("%s -> %s", group.ID, group.Name)
-		}
-		if !roles.HasNext() {
-			break
-		}
+func pager[T any](ctx context.Context, rm *RoleManager, f func(context.Context, ListOptions) (T, error), opts ListOptions, pageNum int) (T, int, error) {
+	var list T
+	opts.Page = pageNum
+	opts.PerPage = rm.pageSize
+	err := rm.call(ctx, func() error {
+		var err error
+		list, err = f(ctx, opts)
+		return err
+	})
+	return list, pageNum + 1, err
+}
 
+func (rm *RoleManager) getAuth0UserGroups(ctx context.Context, name string, orgID string) ([]string, error) {
+	roles, err := rm.getAuth0UserRoles(ctx, name, orgID)
+	if err != nil {
+		return nil, err
 	}
+	return roleInfoNames(roles), nil
 }
 
-func (rm *RoleManager) getAuth0UserGroups(name string) ([]string, error) {
-	res := []string{}
+// getAuth0UserRoles gets the roles of a user, in an organization if orgID
+// is not empty.
+func (rm *RoleManager) getAuth0UserRoles(ctx context.Context, name string, orgID string) ([]RoleInfo, error) {
+	res := []RoleInfo{}
 
-	if _, ok := rm.nameToIDMap[name]; !ok {
-		return nil, errors.New("ID not found for the user")
+	userID, err := rm.userID(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if orgID != "" {
+		return rm.getOrganizationMemberRoles(ctx, orgID, userID)
 	}
 
-	f := func(opts ...management.RequestOption) (*management.RoleList, error) {
-		return rm.mgmtClient.User.Roles(rm.nameToIDMap[name], opts...)
+	f := func(ctx context.Context, opts ListOptions) (*management.RoleList, error) {
+		return rm.api.UserRoles(ctx, userID, opts)
 	}
 
 	for p := 0; ; p++ {
-		roles, _, err := pager(f, p)
+		roles, _, err := pager(ctx, rm, f, ListOptions{}, p)
 		if err != nil {
 			return nil, err
 		}
+		rm.mu.RLock()
 		for _, role := range roles.Roles {
-			res = append(res, *role.Name)
+			if info, ok := rm.auth0RoleInfo(role.GetID(), role.GetName(), role.GetDescription()); ok {
+				res = append(res, info)
+			}
 		}
+		rm.mu.RUnlock()
 		if !roles.HasNext() {
 			break
 		}
@@ -138,91 +348,508 @@ func (rm *RoleManager) getAuth0UserGroups(name string) ([]string, error) {
 	return res, nil
 }
 
-func (rm *RoleManager) getAuth0GroupUsers(name string) ([]string, error) {
-	res := []string{}
+// auth0RoleInfo describes a role assigned in Auth0, or returns false if the
+// role is excluded. rm.mu must be held.
+func (rm *RoleManager) auth0RoleInfo(id string, auth0Name string, description string) (RoleInfo, bool) {
+	name := rm.roleName(auth0Name)
+	if rm.roleExcluded(auth0Name, name) {
+		return RoleInfo{}, false
+	}
+	return RoleInfo{
+		ID:          id,
+		Name:        name,
+		Description: description,
+		Source:      SourceAuth0,
+		Origins:     []Origin{{Source: SourceAuth0}},
+	}, true
+}
 
-	if _, ok := rm.nameToIDMap[name]; !ok {
-		return nil, errors.New("ID not found for the role")
+func (rm *RoleManager) getAuth0GroupUsers(ctx context.Context, name string, orgID string) ([]string, error) {
+	users, err := rm.getAuth0RoleUsers(ctx, name, orgID)
+	if err != nil {
+		return nil, err
 	}
+	return userInfoNames(users), nil
+}
 
-	f := func(opts ...management.RequestOption) (*management.UserList, error) {
-		return rm.mgmtClient.Role.Users(rm.nameToIDMap[name], opts...)
+// getAuth0RoleUsers gets the users of a role, in an organization if orgID
+// is not empty.
+func (rm *RoleManager) getAuth0RoleUsers(ctx context.Context, name string, orgID string) ([]UserInfo, error) {
+	res := []UserInfo{}
+
+	roleID, err := rm.roleID(ctx, name)
+	if err != nil {
+		return nil, err
 	}
-	for p := 0; ; p++ {
-		users, _, err := pager(f, 0)
+	if orgID != "" {
+		return rm.getOrganizationRoleMembers(ctx, orgID, roleID)
+	}
+
+	// Pages of the users of a role stop at 1000 users, checkpoints do not.
+	opts := ListOptions{Take: rm.pageSize}
+	for {
+		var users *management.UserList
+		err := rm.call(ctx, func() error {
+			var err error
+			users, err = rm.api.RoleUsers(ctx, roleID, opts)
+			return err
+		})
 		if err != nil {
 			return nil, err
 		}
 
+		rm.mu.RLock()
 		for _, user := range users.Users {
-			res = append(res, *user.Email)
+			res = append(res, UserInfo{
+				ID:      user.GetID(),
+				Name:    rm.userName(user),
+				Email:   user.GetEmail(),
+				Source:  SourceAuth0,
+				Origins: []Origin{{Source: SourceAuth0}},
+			})
 		}
-		if !users.HasNext() {
+		rm.mu.RUnlock()
+		if users.Next == "" {
 			break
 		}
+		opts.From = users.Next
 	}
 
 	return res, nil
 }
 
-// Clear clears all stored data and resets the role manager to the initial state.
+// Clear resets the role manager to the initial state: the (ID, name)
+// mapping is reloaded from Auth0, and the local role hierarchy from its
+// store, or emptied without one. casbin adds the role links of the policy
+// again after clearing the role manager.
 func (rm *RoleManager) Clear() error {
+	return rm.ClearCtx(context.Background())
+}
+
+// ClearCtx is like Clear, with ctx bounding the Management API calls.
+func (rm *RoleManager) ClearCtx(ctx context.Context) error {
+	rm.mu.RLock()
+	store := rm.store
+	rm.mu.RUnlock()
+
+	hierarchy := newRoleHierarchy()
+	if store != nil {
+		edges, err := store.LoadEdges()
+		if err != nil {
+			return err
+		}
+		hierarchy = newRoleHierarchyFromEdges(edges, rm.logf)
+	}
+
+	rm.mu.Lock()
+	rm.hierarchy = hierarchy
+	rm.mu.Unlock()
+
+	return rm.loadMapping(ctx)
+}
+
+// BuildRelationship is a no-op kept for the casbin v2 interface.
+//
+// Deprecated: BuildRelationship is no longer required by casbin.
+func (rm *RoleManager) BuildRelationship(name1 string, name2 string, domain ...string) error {
 	return nil
 }
 
 // AddLink adds the inheritance link between role: name1 and role: name2.
-// domain is not used.
-func (rm *RoleManager) AddLink(_ string, _ string, _ ...string) error {
-	return errors.New("not implemented")
+// If name1 is a user, the role is assigned to it in Auth0, and created first
+// if it does not exist yet. Links between two Auth0 roles are kept in the
+// local role hierarchy; a *CycleError is returned if the link would create
+// a cycle.
+// domain is an Auth0 Organization, see EnableOrganizations.
+func (rm *RoleManager) AddLink(name1 string, name2 string, domain ...string) error {
+	return rm.AddLinkCtx(context.Background(), name1, name2, domain...)
+}
+
+// AddLinkCtx is like AddLink, with ctx bounding the Management API calls.
+func (rm *RoleManager) AddLinkCtx(ctx context.Context, name1 string, name2 string, domain ...string) error {
+	orgID, err := rm.organizationID(ctx, domain...)
+	if err != nil {
+		return err
+	}
+
+	rm.refreshIfStale()
+
+	if rm.isRole(name1) {
+		if orgID != "" {
+			return errors.New("error: links between roles do not support domains")
+		}
+		if _, err := rm.roleID(ctx, name2); err != nil {
+			return err
+		}
+		return rm.addRoleLink(name1, name2)
+	}
+
+	userID, err := rm.userID(ctx, name1)
+	if err != nil {
+		return err
+	}
+	ok, err := rm.hasRole(ctx, name2)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := rm.createAuth0Role(ctx, name2); err != nil {
+			return err
+		}
+	}
+	roleID, err := rm.roleID(ctx, name2)
+	if err != nil {
+		return err
+	}
+
+	return rm.call(ctx, func() error {
+		if orgID != "" {
+			return rm.api.AssignOrganizationMemberRoles(ctx, orgID, userID, []string{roleID})
+		}
+		return rm.api.AssignUserRoles(ctx, userID, []string{roleID})
+	})
 }
 
 // DeleteLink deletes the inheritance link between role: name1 and role: name2.
-// domain is not used.
-func (rm *RoleManager) DeleteLink(_ string, _ string, _ ...string) error {
-	return errors.New("not implemented")
+// If name1 is a user, the role is removed from it in Auth0.
+// domain is an Auth0 Organization, see EnableOrganizations.
+func (rm *RoleManager) DeleteLink(name1 string, name2 string, domain ...string) error {
+	return rm.DeleteLinkCtx(context.Background(), name1, name2, domain...)
 }
 
-// HasLink determines whether role: name1 inherits role: name2.
-// domain is not used.
-func (rm *RoleManager) HasLink(name1 string, name2 string, domain ...string) (bool, error) {
-	if len(domain) >= 1 {
-		return false, errors.New("error: domain should not be used")
+// DeleteLinkCtx is like DeleteLink, with ctx bounding the Management API calls.
+func (rm *RoleManager) DeleteLinkCtx(ctx context.Context, name1 string, name2 string, domain ...string) error {
+	orgID, err := rm.organizationID(ctx, domain...)
+	if err != nil {
+		return err
 	}
 
-	roles, err := rm.GetRoles(name1)
+	rm.refreshIfStale()
+
+	if rm.isRole(name1) && rm.isRole(name2) {
+		if orgID != "" {
+			return errors.New("error: links between roles do not support domains")
+		}
+		return rm.deleteRoleLink(name1, name2)
+	}
+
+	if rm.isRole(name1) {
+		return errors.New("ID not found for the user")
+	}
+	userID, err := rm.userID(ctx, name1)
+	if err != nil {
+		return err
+	}
+	roleID, err := rm.roleID(ctx, name2)
+	if err != nil {
+		return err
+	}
+
+	return rm.call(ctx, func() error {
+		if orgID != "" {
+			return rm.api.RemoveOrganizationMemberRoles(ctx, orgID, userID, []string{roleID})
+		}
+		return rm.api.RemoveUserRoles(ctx, userID, []string{roleID})
+	})
+}
+
+// createAuth0Role creates a role in Auth0 and adds it to the role mapping.
+// Roles that would not be named name in policies, e.g. because of the role
+// name transform, are not created.
+func (rm *RoleManager) createAuth0Role(ctx context.Context, name string) error {
+	rm.mu.RLock()
+	auth0Name := rm.auth0RoleName(name)
+	excluded := rm.roleExcluded(auth0Name, name)
+	mapped := rm.roleName(auth0Name)
+	rm.mu.RUnlock()
+
+	if excluded {
+		return fmt.Errorf("error: role %s is excluded", name)
+	}
+	if mapped != name {
+		return fmt.Errorf("error: role %s would be created as %s, which is named %s in policies",
+			name, auth0Name, mapped)
+	}
+
+	role := &management.Role{Name: auth0.String(auth0Name)}
+	err := rm.call(ctx, func() error {
+		return rm.api.CreateRole(ctx, role)
+	})
+	if err != nil {
+		return err
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.auth0Roles[role.GetID()] = role
+	rm.indexRoles()
+	if rm.idToNameMap[role.GetID()] != name {
+		return fmt.Errorf("error: role %s was created as %s, which is named %s in policies",
+			name, role.GetName(), rm.idToNameMap[role.GetID()])
+	}
+	return nil
+}
+
+// HasLink determines whether role: name1 inherits role: name2, either
+// directly or through the local role hierarchy. With permission links,
+// name2 may also be an Auth0 permission, see EnablePermissionLinks.
+// domain is an Auth0 Organization, see EnableOrganizations.
+func (rm *RoleManager) HasLink(name1 string, name2 string, domain ...string) (bool, error) {
+	return rm.HasLinkCtx(context.Background(), name1, name2, domain...)
+}
+
+// HasLinkCtx is like HasLink, with ctx bounding the Management API calls.
+func (rm *RoleManager) HasLinkCtx(ctx context.Context, name1 string, name2 string, domain ...string) (bool, error) {
+	_, err := rm.organizationID(ctx, domain...)
 	if err != nil {
 		return false, err
 	}
 
+	rm.refreshIfStale()
+
+	if rm.Match(name1, name2) {
+		return true, nil
+	}
+
+	if rm.permissionLink(name2) && !rm.isRole(name2) && len(rm.matchingRoles(name2)) == 0 {
+		return rm.hasPermission(ctx, name1, name2, domain...)
+	}
+
+	roles := []string{name1}
+	if !rm.isRole(name1) {
+		roles, err = rm.GetRolesCtx(ctx, name1, domain...)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
 	for _, role := range roles {
-		if role == name2 {
+		if rm.match(role, name2) {
 			return true, nil
 		}
+		for _, ancestor := range rm.hierarchy.ancestorsWithin(role, rm.hierarchyLevels()) {
+			if rm.match(ancestor, name2) {
+				return true, nil
+			}
+		}
 	}
 	return false, nil
 }
 
-// GetRoles gets the roles that a subject inherits.
-// domain is not used.
+// GetRoles gets the roles that a subject inherits, followed by its
+// synthetic roles if enabled. With a matching function, see
+// AddMatchingFunc, name can be a pattern of users.
+// domain is an Auth0 Organization, see EnableOrganizations.
 func (rm *RoleManager) GetRoles(name string, domain ...string) ([]string, error) {
-	if len(domain) >= 1 {
-		return nil, errors.New("error: domain should not be used")
+	return rm.GetRolesCtx(context.Background(), name, domain...)
+}
+
+// GetRolesCtx is like GetRoles, with ctx bounding the Management API calls.
+func (rm *RoleManager) GetRolesCtx(ctx context.Context, name string, domain ...string) ([]string, error) {
+	orgID, err := rm.organizationID(ctx, domain...)
+	if err != nil {
+		return nil, err
+	}
+
+	rm.refreshIfStale()
+
+	users := rm.matchingUsers(name)
+	if len(users) == 0 {
+		return rm.getRoles(ctx, name, orgID)
+	}
+	res := []string{}
+	seen := map[string]bool{}
+	for _, user := range users {
+		roles, err := rm.getRoles(ctx, user, orgID)
+		if err != nil {
+			return nil, err
+		}
+		for _, role := range roles {
+			if !seen[role] {
+				seen[role] = true
+				res = append(res, role)
+			}
+		}
 	}
+	return res, nil
+}
+
+// getRoles gets the roles of a user, in an organization if orgID is not
+// empty, followed by its synthetic roles.
+func (rm *RoleManager) getRoles(ctx context.Context, name string, orgID string) ([]string, error) {
+	roles, err := rm.getAuth0UserGroups(ctx, name, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	synthetic := rm.syntheticRoles(name)
 
-	return rm.getAuth0UserGroups(name)
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	for _, role := range synthetic {
+		if !rm.roleExcluded(role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
 }
 
-// GetUsers gets the users that inherits a subject.
-// domain is not used.
+// GetUsers gets the users that inherits a subject, including the users of
+// roles inheriting it through the local role hierarchy.
+// domain is an Auth0 Organization, see EnableOrganizations.
 func (rm *RoleManager) GetUsers(name string, domain ...string) ([]string, error) {
-	if len(domain) >= 1 {
-		return nil, errors.New("error: domain should not be used")
+	return rm.GetUsersCtx(context.Background(), name, domain...)
+}
+
+// GetUsersCtx is like GetUsers, with ctx bounding the Management API calls.
+func (rm *RoleManager) GetUsersCtx(ctx context.Context, name string, domain ...string) ([]string, error) {
+	return rm.GetImplicitUsersForRoleCtx(ctx, name, domain...)
+}
+
+// GetImplicitUsersForRole gets the users that inherit a role directly in
+// Auth0 or transitively through the roles inheriting it. With a matching
+// function, see AddMatchingFunc, name can be a pattern of roles.
+// domain is an Auth0 Organization, see EnableOrganizations.
+func (rm *RoleManager) GetImplicitUsersForRole(name string, domain ...string) ([]string, error) {
+	return rm.GetImplicitUsersForRoleCtx(context.Background(), name, domain...)
+}
+
+// GetImplicitUsersForRoleCtx is like GetImplicitUsersForRole, with ctx bounding the Management API calls.
+func (rm *RoleManager) GetImplicitUsersForRoleCtx(ctx context.Context, name string, domain ...string) ([]string, error) {
+	orgID, err := rm.organizationID(ctx, domain...)
+	if err != nil {
+		return nil, err
+	}
+
+	rm.refreshIfStale()
+
+	roles := rm.matchingRoles(name)
+	if len(roles) == 0 {
+		roles = []string{name}
+	}
+	res := []string{}
+	seen := map[string]bool{}
+	for _, role := range roles {
+		users, err := rm.getImplicitUserInfos(ctx, role, orgID)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range userInfoNames(users) {
+			if !seen[user] {
+				seen[user] = true
+				res = append(res, user)
+			}
+		}
+	}
+	return res, nil
+}
+
+// getImplicitUserInfos gets the users of a role and of the roles inheriting
+// it, in an organization if orgID is not empty.
+func (rm *RoleManager) getImplicitUserInfos(ctx context.Context, name string, orgID string) ([]UserInfo, error) {
+	res, err := rm.getAuth0RoleUsers(ctx, name, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[string]int{}
+	for i, user := range res {
+		index[user.Name] = i
+	}
+	rm.mu.RLock()
+	descendants := rm.hierarchy.descendantsWithin(name, rm.hierarchyLevels())
+	rm.mu.RUnlock()
+
+	for _, role := range descendants {
+		users, err := rm.getAuth0RoleUsers(ctx, role, orgID)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			origin := Origin{Source: SourceHierarchy, Via: role}
+			if i, ok := index[user.Name]; ok {
+				res[i].Origins = append(res[i].Origins, origin)
+				continue
+			}
+			user.Source = origin.Source
+			user.Origins = []Origin{origin}
+			index[user.Name] = len(res)
+			res = append(res, user)
+		}
+	}
+	return res, nil
+}
+
+// GetImplicitRoles gets the roles that a subject inherits, including the
+// roles inherited through the local role hierarchy.
+// domain is an Auth0 Organization, see EnableOrganizations.
+func (rm *RoleManager) GetImplicitRoles(name string, domain ...string) ([]string, error) {
+	roles, err := rm.GetRoleInfos(name, domain...)
+	if err != nil {
+		return nil, err
+	}
+	return roleInfoNames(roles), nil
+}
+
+// GetImplicitUsers gets the users that inherit a role, including the users
+// of roles inheriting it through the local role hierarchy.
+// domain is an Auth0 Organization, see EnableOrganizations.
+func (rm *RoleManager) GetImplicitUsers(name string, domain ...string) ([]string, error) {
+	return rm.GetImplicitUsersForRole(name, domain...)
+}
+
+// GetDomains gets the domains that a user has: the names of the Auth0
+// Organizations it is a member of, or none if organizations are disabled.
+func (rm *RoleManager) GetDomains(name string) ([]string, error) {
+	return rm.GetDomainsCtx(context.Background(), name)
+}
+
+// GetDomainsCtx is like GetDomains, with ctx bounding the Management API calls.
+func (rm *RoleManager) GetDomainsCtx(ctx context.Context, name string) ([]string, error) {
+	if !rm.organizationsEnabled() {
+		return []string{}, nil
+	}
+
+	userID, err := rm.userID(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return rm.listOrganizations(ctx, func(ctx context.Context, opts ListOptions) (*management.OrganizationList, error) {
+		return rm.api.UserOrganizations(ctx, userID, opts)
+	})
+}
+
+// GetAllDomains gets all the domains: the names of all the Auth0
+// Organizations, or none if organizations are disabled.
+func (rm *RoleManager) GetAllDomains() ([]string, error) {
+	return rm.GetAllDomainsCtx(context.Background())
+}
+
+// GetAllDomainsCtx is like GetAllDomains, with ctx bounding the Management API calls.
+func (rm *RoleManager) GetAllDomainsCtx(ctx context.Context) ([]string, error) {
+	if !rm.organizationsEnabled() {
+		return []string{}, nil
 	}
 
-	return rm.getAuth0GroupUsers(name)
+	return rm.listOrganizations(ctx, rm.api.ListOrganizations)
 }
 
-// PrintRoles prints all the roles to log.
-func (rm *RoleManager) PrintRoles() error {
-	return errors.New("not implemented")
+// DeleteDomain deletes all the data of a domain. Organizations are managed
+// in Auth0, so this is not supported.
+func (rm *RoleManager) DeleteDomain(domain string) error {
+	return errors.New("error: domains cannot be deleted")
+}
+
+// SetLogger sets the logger of the role manager. Log messages are printed
+// only if logger is enabled.
+func (rm *RoleManager) SetLogger(logger log.Logger) {
+	rm.logMu.Lock()
+	defer rm.logMu.Unlock()
+
+	rm.logger = logger
 }
@@ -18,14 +18,17 @@ import (
 	"log"
 	"testing"
 
-	"github.com/casbin/casbin"
-	"github.com/casbin/casbin/rbac"
-	"github.com/casbin/casbin/util"
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/rbac"
+	"github.com/casbin/casbin/v2/util"
 )
 
 func testEnforce(t *testing.T, e *casbin.Enforcer, sub string, obj interface{}, act string, res bool) {
 	t.Helper()
-	if e.Enforce(sub, obj, act) != res {
+	myRes, err := e.Enforce(sub, obj, act)
+	if err != nil {
+		t.Errorf("%s, %v, %s: %v", sub, obj, act, err)
+	} else if myRes != res {
 		t.Errorf("%s, %v, %s: %t, supposed to be %t", sub, obj, act, !res, res)
 	}
 }
@@ -89,7 +92,10 @@ func TestRole(t *testing.T) {
 func TestEnforcer(t *testing.T) {
 	// This role manager dose not rely on Casbin policy. So we should not
 	// specify grouping policy ("g" policy rules) in the .csv file.
-	e := casbin.NewEnforcer("examples/rbac_model.conf", "examples/rbac_policy.csv")
+	e, err := casbin.NewEnforcer("examples/rbac_model.conf", "examples/rbac_policy.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// Use our role manager.
 	rm := NewRoleManager(
@@ -104,7 +110,9 @@ func TestEnforcer(t *testing.T) {
 	//
 	// Otherwise, we can set the role manager at any time, because role
 	// manager has nothing to do with the adapter.
-	e.LoadPolicy()
+	if err := e.LoadPolicy(); err != nil {
+		t.Fatal(err)
+	}
 
 	// Current role inheritance tree:
 	//            Group1      Admin
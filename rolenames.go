@@ -0,0 +1,191 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/auth0/go-auth0/management"
+)
+
+// RoleNameTransform maps the name of an Auth0 role to the role name used in
+// policies.
+type RoleNameTransform func(name string) string
+
+// LowercaseRoleNames is a RoleNameTransform lowercasing role names.
+func LowercaseRoleNames(name string) string {
+	return strings.ToLower(name)
+}
+
+// TrimRoleNameSuffixes returns a RoleNameTransform removing the first
+// matching suffix, e.g. an environment suffix like "-prod".
+func TrimRoleNameSuffixes(suffixes ...string) RoleNameTransform {
+	return func(name string) string {
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(name, suffix) {
+				return strings.TrimSuffix(name, suffix)
+			}
+		}
+		return name
+	}
+}
+
+// ChainRoleNameTransforms returns a RoleNameTransform applying transforms in order.
+func ChainRoleNameTransforms(transforms ...RoleNameTransform) RoleNameTransform {
+	return func(name string) string {
+		for _, transform := range transforms {
+			name = transform(name)
+		}
+		return name
+	}
+}
+
+// SetRoleNameTransform sets the transform applied to the names of the roles
+// returned by Auth0, so that policies stay stable when the Auth0 naming
+// conventions change. The role mapping is rebuilt with the new names; the
+// local role hierarchy already uses policy names and is left as is.
+func (rm *RoleManager) SetRoleNameTransform(transform RoleNameTransform) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.roleNameTransform = transform
+	rm.indexRoles()
+}
+
+// SetRoleNameMapping sets a static mapping from Auth0 role names to the role
+// names used in policies, for organizations whose Auth0 naming is owned by
+// another team than the policies. Mapped names take precedence over the
+// role name transform. The role mapping is rebuilt with the new names.
+func (rm *RoleManager) SetRoleNameMapping(mapping map[string]string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.roleNameMapping = map[string]string{}
+	for auth0Name, name := range mapping {
+		rm.roleNameMapping[auth0Name] = name
+	}
+	rm.indexRoles()
+}
+
+// LoadRoleNameMapping reads the role name mapping from a JSON object of
+// Auth0 name -> policy name pairs.
+func (rm *RoleManager) LoadRoleNameMapping(r io.Reader) error {
+	mapping := map[string]string{}
+	if err := json.NewDecoder(r).Decode(&mapping); err != nil {
+		return err
+	}
+	rm.SetRoleNameMapping(mapping)
+	return nil
+}
+
+// SetRoleExclusions sets regular expressions of roles to exclude, e.g.
+// "^internal-.*". Roles whose Auth0 or policy name matches any of them are
+// left out of the role mapping and of all the results, so that
+// infrastructure-only roles never reach authorization decisions.
+func (rm *RoleManager) SetRoleExclusions(patterns ...string) error {
+	exclusions := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		exclusions = append(exclusions, re)
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.roleExclusions = exclusions
+	rm.indexRoles()
+	return nil
+}
+
+// roleExcluded determines whether any of the names of a role is excluded.
+// rm.mu must be held.
+func (rm *RoleManager) roleExcluded(names ...string) bool {
+	for _, re := range rm.roleExclusions {
+		for _, name := range names {
+			if re.MatchString(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// roleName returns the policy name of an Auth0 role name. rm.mu must be held.
+func (rm *RoleManager) roleName(auth0Name string) string {
+	if name, ok := rm.roleNameMapping[auth0Name]; ok {
+		return name
+	}
+	if rm.roleNameTransform != nil {
+		return rm.roleNameTransform(auth0Name)
+	}
+	return auth0Name
+}
+
+// auth0RoleName returns the Auth0 name of a policy role name, reversing the
+// role name mapping. The role name transform cannot be reversed. rm.mu must
+// be held.
+func (rm *RoleManager) auth0RoleName(name string) string {
+	for auth0Name, mapped := range rm.roleNameMapping {
+		if mapped == name {
+			return auth0Name
+		}
+	}
+	return name
+}
+
+// indexRoles rebuilds the (ID, name) mapping of the roles from the roles
+// loaded from Auth0, applying the role name transform. rm.mu must be held
+// for writing.
+func (rm *RoleManager) indexRoles() {
+	for id := range rm.auth0Roles {
+		if name, ok := rm.idToNameMap[id]; ok {
+			if rm.nameToIDMap[name] == id {
+				delete(rm.nameToIDMap, name)
+			}
+			delete(rm.idToNameMap, id)
+		}
+	}
+	rm.roles = map[string]bool{}
+
+	for _, id := range sortedRoleIDs(rm.auth0Roles) {
+		auth0Name := rm.auth0Roles[id].GetName()
+		name := rm.roleName(auth0Name)
+		if rm.roleExcluded(auth0Name, name) {
+			continue
+		}
+		if other, ok := rm.nameToIDMap[name]; ok && rm.roles[name] {
+			rm.logf(LevelWarn, "Roles %s and %s are both named %s, using %s", other, id, name, id)
+		}
+		rm.nameToIDMap[name] = id
+		rm.idToNameMap[id] = name
+		rm.roles[name] = true
+	}
+}
+
+func sortedRoleIDs(roles map[string]*management.Role) []string {
+	ids := make([]string, 0, len(roles))
+	for id := range roles {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/auth0/go-auth0"
+	"github.com/auth0/go-auth0/management"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func newRoleNamesTestRoleManager() *RoleManager {
+	rm := &RoleManager{
+		nameToIDMap: map[string]string{"alice@test.com": "auth0|1"},
+		idToNameMap: map[string]string{"auth0|1": "alice@test.com"},
+		auth0Roles: map[string]*management.Role{
+			"rol_1": {ID: auth0.String("rol_1"), Name: auth0.String("Admin-prod")},
+			"rol_2": {ID: auth0.String("rol_2"), Name: auth0.String("Group1")},
+		},
+		hierarchy: newRoleHierarchy(),
+	}
+	rm.indexRoles()
+	return rm
+}
+
+func TestRoleNameTransform(t *testing.T) {
+	rm := newRoleNamesTestRoleManager()
+	if rm.nameToIDMap["Admin-prod"] != "rol_1" {
+		t.Errorf("Admin-prod: %s, supposed to be rol_1", rm.nameToIDMap["Admin-prod"])
+	}
+
+	rm.SetRoleNameTransform(ChainRoleNameTransforms(TrimRoleNameSuffixes("-dev", "-prod"), LowercaseRoleNames))
+
+	for name, id := range map[string]string{"admin": "rol_1", "group1": "rol_2", "alice@test.com": "auth0|1"} {
+		if rm.nameToIDMap[name] != id {
+			t.Errorf("%s: %s, supposed to be %s", name, rm.nameToIDMap[name], id)
+		}
+	}
+	if _, ok := rm.nameToIDMap["Admin-prod"]; ok {
+		t.Error("Admin-prod should have been renamed")
+	}
+	if rm.idToNameMap["rol_1"] != "admin" {
+		t.Errorf("rol_1: %s, supposed to be admin", rm.idToNameMap["rol_1"])
+	}
+	if !rm.roles["admin"] || rm.roles["Admin-prod"] {
+		t.Errorf("roles: %v, supposed to be [admin group1]", rm.roles)
+	}
+	if rm.roleName("Viewer-dev") != "viewer" {
+		t.Errorf("Viewer-dev: %s, supposed to be viewer", rm.roleName("Viewer-dev"))
+	}
+}
+
+func TestRoleNameMapping(t *testing.T) {
+	rm := newRoleNamesTestRoleManager()
+	rm.SetRoleNameTransform(LowercaseRoleNames)
+	if err := rm.LoadRoleNameMapping(strings.NewReader(`{"Admin-prod": "administrator"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, id := range map[string]string{"administrator": "rol_1", "group1": "rol_2"} {
+		if rm.nameToIDMap[name] != id {
+			t.Errorf("%s: %s, supposed to be %s", name, rm.nameToIDMap[name], id)
+		}
+	}
+
+	// Links between roles use the mapped names.
+	if err := rm.AddLink("administrator", "group1"); err != nil {
+		t.Fatal(err)
+	}
+	testRole(t, rm, "administrator", "group1", true)
+}
+
+func TestRoleExclusions(t *testing.T) {
+	rm := newRoleNamesTestRoleManager()
+	rm.auth0Roles["rol_3"] = &management.Role{ID: auth0.String("rol_3"), Name: auth0.String("internal-deploy")}
+	rm.SetRoleNameMapping(map[string]string{"Group1": "internal-group1"})
+
+	if err := rm.SetRoleExclusions("^internal-.*"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"internal-deploy", "internal-group1"} {
+		if _, ok := rm.nameToIDMap[name]; ok || rm.roles[name] {
+			t.Errorf("%s should be excluded", name)
+		}
+	}
+	if !rm.roles["Admin-prod"] {
+		t.Error("Admin-prod should not be excluded")
+	}
+
+	if err := rm.SetRoleExclusions("("); err == nil {
+		t.Error("invalid patterns should be rejected")
+	}
+}
+
+func TestAuth0RoleName(t *testing.T) {
+	rm := newRoleNamesTestRoleManager()
+	rm.SetRoleNameMapping(map[string]string{"Admin-prod": "administrator"})
+
+	if name := rm.auth0RoleName("administrator"); name != "Admin-prod" {
+		t.Errorf("administrator: %s, supposed to be Admin-prod", name)
+	}
+	if name := rm.auth0RoleName("Group2"); name != "Group2" {
+		t.Errorf("Group2: %s, supposed to be Group2", name)
+	}
+}
+
+func TestCreateAuth0Role(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	rm := newFakeRoleManager(t, fake)
+	rm.SetRoleNameTransform(LowercaseRoleNames)
+	rm.SetRoleNameMapping(map[string]string{"Admin-prod": "administrator"})
+
+	// Roles are created under their mapped Auth0 name.
+	if err := rm.AddLink("alice@example.com", "administrator"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.AddLink("alice@example.com", "editor"); err != nil {
+		t.Fatal(err)
+	}
+	testPrintRoles(t, rm, "alice@example.com", []string{"administrator", "editor"})
+
+	// Roles the transform would rename are not created.
+	if err := rm.AddLink("alice@example.com", "Viewer"); err == nil {
+		t.Error("Viewer should not be created, as it would be named viewer")
+	}
+	if calls := fake.CallCount("CreateRole"); calls != 2 {
+		t.Errorf("created roles: %d, supposed to be 2", calls)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlstore provides a HierarchyStore keeping the local role
+// hierarchy of the role manager in a database/sql table:
+//
+//	store, err := sqlstore.New(db, "role_hierarchy")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := rm.SetHierarchyStore(store); err != nil {
+//		log.Fatal(err)
+//	}
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	auth0rolemanager "github.com/olvesh/auth0-role-manager/v2"
+)
+
+var tableNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+var _ auth0rolemanager.HierarchyStore = (*Store)(nil)
+
+// Store is a HierarchyStore keeping the edges in a database/sql table with
+// a "role" and a "parent" column.
+type Store struct {
+	db          *sql.DB
+	table       string
+	placeholder func(n int) string
+}
+
+// New is the constructor of a Store. The table is created if it does not
+// exist yet. Queries use "?" placeholders; call SetPlaceholder for drivers
+// expecting another syntax, like PostgreSQL.
+func New(db *sql.DB, table string) (*Store, error) {
+	return NewCtx(context.Background(), db, table)
+}
+
+// NewCtx is like New, with ctx bounding the creation of the table.
+func NewCtx(ctx context.Context, db *sql.DB, table string) (*Store, error) {
+	if !tableNameRegexp.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name: %q", table)
+	}
+
+	s := &Store{
+		db:    db,
+		table: table,
+		placeholder: func(int) string {
+			return "?"
+		},
+	}
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (role VARCHAR(255) NOT NULL, parent VARCHAR(255) NOT NULL, PRIMARY KEY (role, parent))",
+		table))
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetPlaceholder sets the function generating the n-th (starting at 1)
+// query placeholder, e.g. DollarPlaceholder for PostgreSQL.
+func (s *Store) SetPlaceholder(placeholder func(n int) string) {
+	s.placeholder = placeholder
+}
+
+// DollarPlaceholder generates PostgreSQL style placeholders: $1, $2, ...
+func DollarPlaceholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// LoadEdges returns all the stored edges.
+func (s *Store) LoadEdges() ([]auth0rolemanager.Edge, error) {
+	return s.LoadEdgesCtx(context.Background())
+}
+
+// LoadEdgesCtx is like LoadEdges, with ctx bounding the query.
+func (s *Store) LoadEdgesCtx(ctx context.Context) ([]auth0rolemanager.Edge, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT role, parent FROM %s", s.table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edges := []auth0rolemanager.Edge{}
+	for rows.Next() {
+		var e auth0rolemanager.Edge
+		if err := rows.Scan(&e.Role, &e.Parent); err != nil {
+			return nil, err
+		}
+		edges = append(edges, e)
+	}
+	return edges, rows.Err()
+}
+
+// AddEdge stores a single edge.
+func (s *Store) AddEdge(edge auth0rolemanager.Edge) error {
+	return s.AddEdgeCtx(context.Background(), edge)
+}
+
+// AddEdgeCtx is like AddEdge, with ctx bounding the queries. Edges are
+// unique by the primary key of the table, so an insert failing because
+// another process stored the same edge first is not an error.
+func (s *Store) AddEdgeCtx(ctx context.Context, edge auth0rolemanager.Edge) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (role, parent) VALUES (%s, %s)",
+		s.table, s.placeholder(1), s.placeholder(2)), edge.Role, edge.Parent)
+	if err == nil {
+		return nil
+	}
+
+	// Duplicate key errors differ between drivers: look for the edge instead.
+	var n int
+	countErr := s.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE role = %s AND parent = %s",
+		s.table, s.placeholder(1), s.placeholder(2)), edge.Role, edge.Parent).Scan(&n)
+	if countErr == nil && n > 0 {
+		return nil
+	}
+	return err
+}
+
+// RemoveEdge deletes a single edge.
+func (s *Store) RemoveEdge(edge auth0rolemanager.Edge) error {
+	return s.RemoveEdgeCtx(context.Background(), edge)
+}
+
+// RemoveEdgeCtx is like RemoveEdge, with ctx bounding the query.
+func (s *Store) RemoveEdgeCtx(ctx context.Context, edge auth0rolemanager.Edge) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE role = %s AND parent = %s",
+		s.table, s.placeholder(1), s.placeholder(2)), edge.Role, edge.Parent)
+	return err
+}
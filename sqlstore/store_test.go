@@ -0,0 +1,132 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	auth0rolemanager "github.com/olvesh/auth0-role-manager/v2"
+)
+
+func TestStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS role_hierarchy")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s, err := New(db, "role_hierarchy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetPlaceholder(DollarPlaceholder)
+
+	// Edges stored already, e.g. by another process, are not an error.
+	edge := auth0rolemanager.Edge{Role: "admin", Parent: "editor"}
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO role_hierarchy (role, parent) VALUES ($1, $2)")).
+		WithArgs("admin", "editor").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO role_hierarchy")).
+		WithArgs("admin", "editor").
+		WillReturnError(errors.New("duplicate key value violates unique constraint"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM role_hierarchy WHERE role = $1 AND parent = $2")).
+		WithArgs("admin", "editor").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	if err := s.AddEdge(edge); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddEdge(edge); err != nil {
+		t.Fatal(err)
+	}
+
+	// Other insert errors are returned.
+	failed := errors.New("connection reset")
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO role_hierarchy")).
+		WithArgs("admin", "viewer").
+		WillReturnError(failed)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM role_hierarchy")).
+		WithArgs("admin", "viewer").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	if err := s.AddEdge(auth0rolemanager.Edge{Role: "admin", Parent: "viewer"}); err != failed {
+		t.Errorf("AddEdge: %v, supposed to be %v", err, failed)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT role, parent FROM role_hierarchy")).
+		WillReturnRows(sqlmock.NewRows([]string{"role", "parent"}).AddRow("admin", "editor"))
+	edges, err := s.LoadEdges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []auth0rolemanager.Edge{edge}; !reflect.DeepEqual(edges, want) {
+		t.Errorf("edges: %v, supposed to be %v", edges, want)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM role_hierarchy WHERE role = $1 AND parent = $2")).
+		WithArgs("admin", "editor").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := s.RemoveEdge(edge); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestStoreCanceledContext(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("CREATE TABLE").WillReturnResult(sqlmock.NewResult(0, 0))
+	s, err := New(db, "role_hierarchy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.LoadEdgesCtx(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("LoadEdgesCtx: %v, supposed to be canceled", err)
+	}
+	if err := s.AddEdgeCtx(ctx, auth0rolemanager.Edge{Role: "admin", Parent: "editor"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("AddEdgeCtx: %v, supposed to be canceled", err)
+	}
+	if err := s.RemoveEdgeCtx(ctx, auth0rolemanager.Edge{Role: "admin", Parent: "editor"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("RemoveEdgeCtx: %v, supposed to be canceled", err)
+	}
+}
+
+func TestInvalidTableName(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := New(db, "edges; DROP TABLE users"); err == nil {
+		t.Error("invalid table names should be rejected")
+	}
+}
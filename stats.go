@@ -0,0 +1,102 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// APICall describes a Management API request, reported to the hook set by
+// WithAPICallHook once it is done, retries included.
+type APICall struct {
+	Method string
+	// Path is the URL path of the request, e.g. "/api/v2/users/auth0|123/roles".
+	Path string
+	// StatusCode is the status of the last response, or 0 if none was received.
+	StatusCode int
+	// RateLimited is the number of 429 responses received.
+	RateLimited int
+	Duration    time.Duration
+	Err         error
+}
+
+// Stats are counters of the activity of a role manager, e.g. to export as
+// metrics. Counters only increase over the life of the role manager.
+type Stats struct {
+	// APICalls is the number of Management API requests, retries excluded.
+	APICalls uint64
+	// APIErrors is the number of requests that failed or returned an error
+	// status.
+	APIErrors uint64
+	// RateLimited is the number of 429 responses received.
+	RateLimited uint64
+	// MappingLoads is the number of loads of the (ID, name) mapping,
+	// including the reloads of Refresh, Clear and a stale mapping.
+	MappingLoads uint64
+	// MappingLoadErrors is the number of loads that failed.
+	MappingLoadErrors uint64
+	// CachedMisses is the number of lookups of users and roles answered
+	// without calling Auth0, as they were recently not found, see
+	// WithLookupMissTTL.
+	CachedMisses uint64
+
+	// Users and Roles are the current sizes of the mapping.
+	Users int
+	Roles int
+	// LastLoad is when the mapping was last loaded, zero if never.
+	LastLoad time.Time
+}
+
+// stats holds the counters of Stats.
+type stats struct {
+	apiCalls          atomic.Uint64
+	apiErrors         atomic.Uint64
+	rateLimited       atomic.Uint64
+	mappingLoads      atomic.Uint64
+	mappingLoadErrors atomic.Uint64
+	cachedMisses      atomic.Uint64
+}
+
+// Stats returns the counters of the activity of the role manager.
+func (rm *RoleManager) Stats() Stats {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	return Stats{
+		APICalls:          rm.stats.apiCalls.Load(),
+		APIErrors:         rm.stats.apiErrors.Load(),
+		RateLimited:       rm.stats.rateLimited.Load(),
+		MappingLoads:      rm.stats.mappingLoads.Load(),
+		MappingLoadErrors: rm.stats.mappingLoadErrors.Load(),
+		CachedMisses:      rm.stats.cachedMisses.Load(),
+		Users:             len(rm.profiles),
+		Roles:             len(rm.roles),
+		LastLoad:          rm.loadedAt,
+	}
+}
+
+// observeAPICall counts a Management API request and reports it to the
+// hook, if any.
+func (rm *RoleManager) observeAPICall(call APICall) {
+	rm.stats.apiCalls.Add(1)
+	if call.Err != nil || call.StatusCode >= 400 {
+		rm.stats.apiErrors.Add(1)
+	}
+	rm.stats.rateLimited.Add(uint64(call.RateLimited))
+	if rm.apiCallHook != nil {
+		rm.apiCallHook(call)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+// recordingLogger records the messages logged at or above a level.
+type recordingLogger struct {
+	mu       sync.Mutex
+	minLevel Level
+	messages []string
+}
+
+func (l *recordingLogger) Logf(level Level, format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if level >= l.minLevel {
+		l.messages = append(l.messages, level.String()+": "+fmt.Sprintf(format, v...))
+	}
+}
+
+func TestStats(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/users-by-email", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"user_id": "auth0|alice", "email": "alice@example.com"}]`)
+	})
+	mux.HandleFunc("/api/v2/users/auth0|alice/roles", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	calls := []APICall{}
+	logger := &recordingLogger{minLevel: LevelDebug}
+	rm := newTestRoleManager(t, mux,
+		WithLeveledLogger(logger),
+		WithPIIRedaction(),
+		WithAPICallHook(func(call APICall) {
+			calls = append(calls, call)
+		}))
+
+	if _, err := rm.GetRoles("alice@example.com"); err == nil {
+		t.Error("the failing role listing should fail GetRoles")
+	}
+
+	if len(calls) != 2 || calls[0].Path != "/api/v2/users-by-email" || calls[1].StatusCode != http.StatusInternalServerError {
+		t.Errorf("API calls: %+v, supposed to be the user lookup and the failed role listing", calls)
+	}
+	stats := rm.Stats()
+	if stats.APICalls != 2 || stats.APIErrors != 1 || stats.Users != 1 {
+		t.Errorf("stats: %+v, supposed to count 2 calls, 1 error and 1 user", stats)
+	}
+
+	for _, message := range logger.messages {
+		if strings.Contains(message, "alice@") {
+			t.Errorf("message %q should have been redacted", message)
+		}
+	}
+	if len(logger.messages) != 2 || logger.messages[1] != "debug: auth0|alice -> a***@example.com" {
+		t.Errorf("messages: %q, supposed to be the lookup of a***@example.com", logger.messages)
+	}
+}
+
+func TestMappingLoadStats(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	rm := newFakeRoleManager(t, fake, WithMappingTTL(time.Minute))
+	if err := rm.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.Clear(); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.Intercept(func(c auth0test.Call) error {
+		return &auth0test.Error{StatusCode: http.StatusInternalServerError, Message: "failed"}
+	})
+	if err := rm.Clear(); err == nil {
+		t.Error("the failing user listing should fail Clear")
+	}
+
+	if stats := rm.Stats(); stats.MappingLoads != 4 || stats.MappingLoadErrors != 1 {
+		t.Errorf("stats: %+v, supposed to count 4 loads and 1 error", stats)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Edge is a link of the local role hierarchy: Role inherits Parent.
+type Edge struct {
+	Role   string
+	Parent string
+}
+
+// HierarchyStore persists the local role hierarchy so that role -> role
+// links added via AddLink survive restarts.
+type HierarchyStore interface {
+	// LoadEdges returns all the stored edges.
+	LoadEdges() ([]Edge, error)
+	// AddEdge stores a single edge. Storing an existing edge is not an error.
+	AddEdge(edge Edge) error
+	// RemoveEdge deletes a single edge. Removing a missing edge is not an error.
+	RemoveEdge(edge Edge) error
+}
+
+// FileHierarchyStore is a HierarchyStore keeping the edges in a CSV file,
+// one "role, parent" pair per line. Names containing commas or quotes are
+// quoted.
+type FileHierarchyStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileHierarchyStore is the constructor of a FileHierarchyStore.
+// The file is created on the first write if it does not exist yet.
+func NewFileHierarchyStore(path string) *FileHierarchyStore {
+	return &FileHierarchyStore{path: path}
+}
+
+// LoadEdges returns all the stored edges.
+func (s *FileHierarchyStore) LoadEdges() ([]Edge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load()
+}
+
+// AddEdge stores a single edge.
+func (s *FileHierarchyStore) AddEdge(edge Edge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	edges, err := s.load()
+	if err != nil {
+		return err
+	}
+	for _, e := range edges {
+		if e == edge {
+			return nil
+		}
+	}
+	return s.save(append(edges, edge))
+}
+
+// RemoveEdge deletes a single edge.
+func (s *FileHierarchyStore) RemoveEdge(edge Edge) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	edges, err := s.load()
+	if err != nil {
+		return err
+	}
+	res := edges[:0]
+	for _, e := range edges {
+		if e != edge {
+			res = append(res, e)
+		}
+	}
+	return s.save(res)
+}
+
+func (s *FileHierarchyStore) load() ([]Edge, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	edges := []Edge{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.path, err)
+		}
+		if len(record) != 2 {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf("%s:%d: invalid edge: %q", s.path, line, record)
+		}
+		edges = append(edges, Edge{Role: record[0], Parent: record[1]})
+	}
+	return edges, nil
+}
+
+// save writes the edges to a temporary file and renames it over the store,
+// so a crash never leaves a half-written hierarchy behind.
+func (s *FileHierarchyStore) save(edges []Edge) error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := csv.NewWriter(tmp)
+	for _, e := range edges {
+		if err := w.Write([]string{e.Role, e.Parent}); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
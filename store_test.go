@@ -0,0 +1,95 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileHierarchyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hierarchy.csv")
+	s := NewFileHierarchyStore(path)
+
+	edges, err := s.LoadEdges()
+	if err != nil || len(edges) != 0 {
+		t.Fatalf("empty store: %v, %v", edges, err)
+	}
+
+	rm := &RoleManager{
+		roles:     map[string]bool{"admin": true, "editor": true, "viewer": true},
+		hierarchy: newRoleHierarchy(),
+	}
+	if err := rm.SetHierarchyStore(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.AddLink("admin", "editor"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.AddLink("editor", "viewer"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.AddLink("editor", "viewer"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.DeleteLink("admin", "editor"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new role manager restores the hierarchy from the same file.
+	rm2 := &RoleManager{
+		roles:     map[string]bool{"admin": true, "editor": true, "viewer": true},
+		hierarchy: newRoleHierarchy(),
+	}
+	if err := rm2.SetHierarchyStore(NewFileHierarchyStore(path)); err != nil {
+		t.Fatal(err)
+	}
+	testRole(t, rm2, "editor", "viewer", true)
+	testRole(t, rm2, "admin", "editor", false)
+
+	edges, _ = s.LoadEdges()
+	if len(edges) != 1 || edges[0] != (Edge{Role: "editor", Parent: "viewer"}) {
+		t.Errorf("stored edges: %v, supposed to be [{editor viewer}]", edges)
+	}
+}
+
+func TestFileHierarchyStoreQuoting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hierarchy.csv")
+	if err := os.WriteFile(path, []byte("# role, parent\nadmin, editor\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := NewFileHierarchyStore(path)
+
+	// Names with commas and quotes survive a round trip.
+	edge := Edge{Role: `ops, "eu"`, Parent: "admin"}
+	if err := s.AddEdge(edge); err != nil {
+		t.Fatal(err)
+	}
+	edges, err := s.LoadEdges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edges) != 2 || edges[0] != (Edge{Role: "admin", Parent: "editor"}) || edges[1] != edge {
+		t.Errorf("edges: %q, supposed to be [{admin editor} %q]", edges, edge)
+	}
+
+	if err := os.WriteFile(path, []byte("admin, editor, viewer\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.LoadEdges(); err == nil {
+		t.Error("lines of 3 fields should be rejected")
+	}
+}
@@ -0,0 +1,350 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/auth0/go-auth0/management"
+)
+
+// Kinds of the changes applied by ProcessEvent.
+const (
+	// ChangeUser is a user created or updated in Auth0.
+	ChangeUser = "user"
+	// ChangeUserDeleted is a user deleted from Auth0.
+	ChangeUserDeleted = "user_deleted"
+	// ChangeRole is a role created or updated in Auth0.
+	ChangeRole = "role"
+	// ChangeRoleDeleted is a role deleted from Auth0.
+	ChangeRoleDeleted = "role_deleted"
+	// ChangeAssignment is a role assigned to or removed from users.
+	ChangeAssignment = "assignment"
+)
+
+// maxLogEventsSize is the largest request body accepted by SyncHandler.
+// Auth0 delivers Log Stream events in batches of at most a few hundred.
+const maxLogEventsSize = 10 << 20
+
+// Change is a change of the Auth0 state applied by ProcessEvent.
+type Change struct {
+	Kind string
+	// UserID is the Auth0 ID of the user changed, if any.
+	UserID string
+	// RoleID is the Auth0 ID of the role changed, if any.
+	RoleID string
+}
+
+// LogEvent is an Auth0 log event, as delivered by a Log Stream webhook.
+type LogEvent struct {
+	LogID string       `json:"log_id"`
+	Data  LogEventData `json:"data"`
+}
+
+// LogEventData is the content of a LogEvent. Only the fields needed to sync
+// the role manager are decoded.
+type LogEventData struct {
+	// Type is the event type code, e.g. "ss" for a successful signup or
+	// "sapi" for a successful Management API operation.
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	UserID      string `json:"user_id"`
+	Details     struct {
+		Request struct {
+			Method string `json:"method"`
+			Path   string `json:"path"`
+		} `json:"request"`
+		Response struct {
+			Body json.RawMessage `json:"body"`
+		} `json:"response"`
+	} `json:"details"`
+}
+
+// SetChangeHandler sets a function called with every change applied by
+// ProcessEvent, e.g. to invalidate a CachedRoleManager wrapping the role
+// manager.
+func (rm *RoleManager) SetChangeHandler(handler func(Change)) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.changeHandler = handler
+}
+
+// SyncHandler returns an http.Handler receiving the events of an Auth0 Log
+// Stream of type Custom Webhook, in the JSON Array, JSON Lines or JSON
+// Object format, and passing them to ProcessEvent. Requests must carry
+// token as Authorization header, as configured in the Log Stream. If token
+// is empty, e.g. because it is missing from the configuration, every request
+// is rejected. Bodies larger than 10 MB are rejected too.
+//
+// A request fails with 500 if any of its events failed, so that Auth0
+// delivers it again.
+func (rm *RoleManager) SyncHandler(token string) http.Handler {
+	if token == "" {
+		rm.logf(LevelError, "Sync handler without token, rejecting every request")
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		events, err := decodeLogEvents(http.MaxBytesReader(w, r.Body, maxLogEventsSize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		failed := false
+		for _, event := range events {
+			if err := rm.ProcessEvent(r.Context(), event); err != nil {
+				rm.logf(LevelError, "Error processing log event %s: '%v'", event.LogID, err)
+				failed = true
+			}
+		}
+		if failed {
+			http.Error(w, "some events could not be processed", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// decodeLogEvents decodes a JSON array of events, or a stream of JSON
+// objects.
+func decodeLogEvents(r io.Reader) ([]LogEvent, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.TrimSpace(body)
+
+	events := []LogEvent{}
+	if bytes.HasPrefix(body, []byte("[")) {
+		err := json.Unmarshal(body, &events)
+		return events, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for dec.More() {
+		var event LogEvent
+		if err := dec.Decode(&event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// ProcessEvent applies an Auth0 log event to the (ID, name) mapping, so that
+// it stays fresh without reloading the whole tenant: created, updated and
+// deleted users and roles are added, renamed or removed. The users and
+// roles are read again from Auth0, so events may be replayed or delivered
+// out of order. Events of other types are ignored.
+//
+// Role assignments are not kept by the role manager, but reported to the
+// change handler along with the other changes, see SetChangeHandler.
+func (rm *RoleManager) ProcessEvent(ctx context.Context, event LogEvent) error {
+	change, ok := logEventChange(event.Data)
+	if !ok {
+		return nil
+	}
+
+	var err error
+	switch change.Kind {
+	case ChangeUser:
+		err = rm.syncUser(ctx, change.UserID)
+	case ChangeUserDeleted:
+		rm.removeUser(change.UserID)
+	case ChangeRole:
+		err = rm.syncRole(ctx, change.RoleID)
+	case ChangeRoleDeleted:
+		rm.removeRole(change.RoleID)
+	}
+	if err != nil {
+		return err
+	}
+
+	rm.mu.RLock()
+	handler := rm.changeHandler
+	rm.mu.RUnlock()
+	if handler != nil {
+		handler(change)
+	}
+	return nil
+}
+
+// logEventChange returns the change described by a log event, if any.
+func logEventChange(data LogEventData) (Change, bool) {
+	switch data.Type {
+	case "ss":
+		return Change{Kind: ChangeUser, UserID: data.UserID}, data.UserID != ""
+	case "sdu":
+		return Change{Kind: ChangeUserDeleted, UserID: data.UserID}, data.UserID != ""
+	case "sapi":
+	default:
+		return Change{}, false
+	}
+
+	method := strings.ToUpper(data.Details.Request.Method)
+	path := strings.Split(strings.Trim(strings.TrimPrefix(data.Details.Request.Path, "/api/v2"), "/"), "/")
+	for i, segment := range path {
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			path[i] = unescaped
+		}
+	}
+	var created struct {
+		ID     string `json:"id"`
+		UserID string `json:"user_id"`
+	}
+	_ = json.Unmarshal(data.Details.Response.Body, &created)
+
+	switch {
+	case len(path) == 1 && path[0] == "users" && method == http.MethodPost:
+		return Change{Kind: ChangeUser, UserID: created.UserID}, created.UserID != ""
+	case len(path) == 2 && path[0] == "users" && method == http.MethodPatch:
+		return Change{Kind: ChangeUser, UserID: path[1]}, true
+	case len(path) == 2 && path[0] == "users" && method == http.MethodDelete:
+		return Change{Kind: ChangeUserDeleted, UserID: path[1]}, true
+	case len(path) == 3 && path[0] == "users" && path[2] == "roles":
+		return Change{Kind: ChangeAssignment, UserID: path[1]}, true
+	case len(path) == 1 && path[0] == "roles" && method == http.MethodPost:
+		return Change{Kind: ChangeRole, RoleID: created.ID}, created.ID != ""
+	case len(path) == 2 && path[0] == "roles" && method == http.MethodPatch:
+		return Change{Kind: ChangeRole, RoleID: path[1]}, true
+	case len(path) == 2 && path[0] == "roles" && method == http.MethodDelete:
+		return Change{Kind: ChangeRoleDeleted, RoleID: path[1]}, true
+	case len(path) == 3 && path[0] == "roles" && path[2] == "users":
+		return Change{Kind: ChangeAssignment, RoleID: path[1]}, true
+	case len(path) == 5 && path[0] == "organizations" && path[2] == "members" && path[4] == "roles":
+		return Change{Kind: ChangeAssignment, UserID: path[3]}, true
+	}
+	return Change{}, false
+}
+
+// syncUser reads a user from Auth0 and adds it to the mapping, replacing
+// its former name at once. Users not matching the user query are removed.
+func (rm *RoleManager) syncUser(ctx context.Context, id string) error {
+	rm.mu.RLock()
+	fields := rm.userFields()
+	query := rm.userQuery()
+	rm.mu.RUnlock()
+
+	users, err := rm.findUsers(ctx, id, "user_id", query, fields)
+	if err != nil {
+		return err
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.dropUser(id)
+	if len(users) == 0 {
+		return nil
+	}
+	user := users[0]
+	name := rm.identity(user)
+	if name == "" {
+		rm.logf(LevelWarn, "User %s has no name, skipping it", id)
+		return nil
+	}
+	if rm.roles[name] {
+		rm.logf(LevelWarn, "User %s is named %s like a role, skipping it", id, rm.pii(name))
+		return nil
+	}
+	rm.nameToIDMap[name] = id
+	rm.idToNameMap[id] = name
+	rm.profiles[id] = user
+	rm.logf(LevelDebug, "%s -> %s", id, rm.pii(name))
+	return nil
+}
+
+// removeUser removes a user from the mapping.
+func (rm *RoleManager) removeUser(id string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.dropUser(id)
+}
+
+// dropUser removes a user from the mapping. rm.mu must be held for writing.
+func (rm *RoleManager) dropUser(id string) {
+	if name, ok := rm.idToNameMap[id]; ok && !rm.roles[name] {
+		if rm.nameToIDMap[name] == id {
+			delete(rm.nameToIDMap, name)
+		}
+		delete(rm.idToNameMap, id)
+	}
+	delete(rm.profiles, id)
+}
+
+// syncRole reads a role from Auth0 and adds it to the mapping, replacing
+// its former name.
+func (rm *RoleManager) syncRole(ctx context.Context, id string) error {
+	var role *management.Role
+	err := rm.call(ctx, func() error {
+		var err error
+		role, err = rm.api.ReadRole(ctx, id)
+		return err
+	})
+	if mErr, ok := err.(management.Error); ok && mErr.Status() == http.StatusNotFound {
+		rm.removeRole(id)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.auth0Roles[id] = role
+	rm.indexRoles()
+	return nil
+}
+
+// removeRole removes a role from the mapping.
+func (rm *RoleManager) removeRole(id string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if _, ok := rm.auth0Roles[id]; !ok {
+		return
+	}
+	if name, ok := rm.idToNameMap[id]; ok {
+		if rm.nameToIDMap[name] == id {
+			delete(rm.nameToIDMap, name)
+		}
+		delete(rm.idToNameMap, id)
+	}
+	delete(rm.auth0Roles, id)
+	rm.indexRoles()
+}
@@ -0,0 +1,131 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/auth0/go-auth0"
+	"github.com/auth0/go-auth0/management"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestSync(t *testing.T) {
+	fake := auth0test.New()
+	addUser(fake, "auth0|alice", "alice@example.com")
+	addUser(fake, "auth0|bob", "bob@example.com")
+	addRole(fake, "rol_admin", "admin")
+	rm := newFakeRoleManager(t, fake)
+
+	// The changes logged below.
+	addUser(fake, "auth0|carol", "carol@example.com")
+	fake.DeleteUser("auth0|bob")
+	fake.AddUser(&management.User{ID: auth0.String("auth0|alice"), Email: auth0.String("alice@example.org")})
+	addRole(fake, "rol_viewer", "viewer")
+	fake.DeleteRole("rol_admin")
+
+	changes := []Change{}
+	rm.SetChangeHandler(func(change Change) {
+		changes = append(changes, change)
+	})
+
+	body := `[
+		{"log_id": "1", "data": {"type": "ss", "user_id": "auth0|carol"}},
+		{"log_id": "2", "data": {"type": "sdu", "user_id": "auth0|bob"}},
+		{"log_id": "3", "data": {"type": "sapi", "details": {"request": {"method": "patch", "path": "/api/v2/users/auth0%7Calice"}}}},
+		{"log_id": "4", "data": {"type": "sapi", "details": {"request": {"method": "post", "path": "/api/v2/roles"}, "response": {"body": {"id": "rol_viewer", "name": "viewer"}}}}},
+		{"log_id": "5", "data": {"type": "sapi", "details": {"request": {"method": "delete", "path": "/api/v2/roles/rol_admin"}}}},
+		{"log_id": "6", "data": {"type": "sapi", "details": {"request": {"method": "post", "path": "/api/v2/users/auth0|carol/roles"}}}},
+		{"log_id": "7", "data": {"type": "fp", "user_id": "auth0|carol"}}
+	]`
+	handler := rm.SyncHandler("secret")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status without token: %d, supposed to be 401", w.Code)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set("Authorization", "secret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status: %d, supposed to be 204: %s", w.Code, w.Body)
+	}
+
+	expected := map[string]string{
+		"alice@example.org": "auth0|alice",
+		"carol@example.com": "auth0|carol",
+		"viewer":            "rol_viewer",
+	}
+	if len(rm.nameToIDMap) != len(expected) {
+		t.Errorf("mapping: %v, supposed to be %v", rm.nameToIDMap, expected)
+	}
+	for name, id := range expected {
+		if rm.nameToIDMap[name] != id || rm.idToNameMap[id] != name {
+			t.Errorf("%s: %s, supposed to be %s", name, rm.nameToIDMap[name], id)
+		}
+	}
+	if !rm.roles["viewer"] || rm.roles["admin"] {
+		t.Errorf("roles: %v, supposed to be [viewer]", rm.roles)
+	}
+
+	if len(changes) != 6 {
+		t.Fatalf("changes: %v, supposed to be 6", changes)
+	}
+	if changes[5] != (Change{Kind: ChangeAssignment, UserID: "auth0|carol"}) {
+		t.Errorf("change: %v, supposed to be an assignment of auth0|carol", changes[5])
+	}
+}
+
+func TestSyncHandlerLimits(t *testing.T) {
+	fake := auth0test.New()
+	rm := newFakeRoleManager(t, fake)
+
+	// Without a token, every request is rejected.
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("[]"))
+	w := httptest.NewRecorder()
+	rm.SyncHandler("").ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status without configured token: %d, supposed to be 401", w.Code)
+	}
+
+	// Large bodies are rejected.
+	body := "[" + strings.Repeat(" ", maxLogEventsSize) + "]"
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set("Authorization", "secret")
+	w = httptest.NewRecorder()
+	rm.SyncHandler("secret").ServeHTTP(w, r)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status of a large body: %d, supposed to be 413", w.Code)
+	}
+}
+
+func TestDecodeLogEvents(t *testing.T) {
+	events, err := decodeLogEvents(strings.NewReader(`{"log_id": "1", "data": {"type": "ss"}}
+{"log_id": "2", "data": {"type": "sdu"}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[1].LogID != "2" || events[1].Data.Type != "sdu" {
+		t.Errorf("events: %v, supposed to be 2 JSON lines", events)
+	}
+}
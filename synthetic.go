@@ -0,0 +1,259 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/auth0/go-auth0/management"
+)
+
+// Synthetic roles surfacing the state of an Auth0 account, see
+// EnableAccountStateRoles.
+const (
+	// RoleBlocked is given to blocked users.
+	RoleBlocked = "auth0:blocked"
+	// RoleEmailUnverified is given to users with an email that is not
+	// verified.
+	RoleEmailUnverified = "auth0:email_unverified"
+)
+
+// EnableAccountStateRoles makes GetRoles return the synthetic roles
+// RoleBlocked and RoleEmailUnverified for the users in these states, so
+// that policies can deny or restrict such accounts explicitly. The states
+// are taken from the user profiles loaded with the (ID, name) mapping.
+func (rm *RoleManager) EnableAccountStateRoles(enable bool) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.accountStateRoles = enable
+}
+
+// syntheticRoles returns the synthetic roles of a user. The synthetic role
+// provider is called without rm.mu held, so that it can call the role
+// manager.
+func (rm *RoleManager) syntheticRoles(name string) []string {
+	res := []string{}
+
+	rm.mu.RLock()
+	user, ok := rm.profiles[rm.nameToIDMap[name]]
+	if !ok {
+		rm.mu.RUnlock()
+		return res
+	}
+
+	for _, rule := range rm.metadataRules {
+		if rule.matches(user) {
+			res = append(res, rule.Role)
+		}
+	}
+
+	if rm.accountStateRoles {
+		if user.GetBlocked() {
+			res = append(res, RoleBlocked)
+		}
+		if user.GetEmail() != "" && !user.GetEmailVerified() {
+			res = append(res, RoleEmailUnverified)
+		}
+	}
+
+	provider := rm.syntheticRoleProvider
+	var view ProfileView
+	if provider != nil {
+		view = newProfileView(user)
+	}
+	rm.mu.RUnlock()
+
+	if provider != nil {
+		res = append(res, provider(view)...)
+	}
+	return res
+}
+
+// ProfileView is a read-only view of the Auth0 profile of a user, as loaded
+// with the (ID, name) mapping. The metadata maps are shared with the role
+// manager and must not be modified.
+type ProfileView struct {
+	ID            string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Nickname      string
+	Name          string
+	Blocked       bool
+	// Connections are the connections of the user's identities.
+	Connections  []string
+	CreatedAt    time.Time
+	LastLogin    time.Time
+	LastIP       string
+	LoginsCount  int64
+	AppMetadata  map[string]interface{}
+	UserMetadata map[string]interface{}
+}
+
+func newProfileView(user *management.User) ProfileView {
+	view := ProfileView{
+		ID:            user.GetID(),
+		Email:         user.GetEmail(),
+		EmailVerified: user.GetEmailVerified(),
+		Username:      user.GetUsername(),
+		Nickname:      user.GetNickname(),
+		Name:          user.GetName(),
+		Blocked:       user.GetBlocked(),
+		Connections:   []string{},
+		CreatedAt:     user.GetCreatedAt(),
+		LastLogin:     user.GetLastLogin(),
+		LastIP:        user.GetLastIP(),
+		LoginsCount:   user.GetLoginsCount(),
+		AppMetadata:   map[string]interface{}{},
+		UserMetadata:  map[string]interface{}{},
+	}
+	for _, identity := range user.Identities {
+		view.Connections = append(view.Connections, identity.GetConnection())
+	}
+	if user.AppMetadata != nil {
+		view.AppMetadata = *user.AppMetadata
+	}
+	if user.UserMetadata != nil {
+		view.UserMetadata = *user.UserMetadata
+	}
+	return view
+}
+
+// SyntheticRoleProvider computes synthetic roles from the profile of a user,
+// e.g. based on the account age or the location.
+type SyntheticRoleProvider func(user ProfileView) []string
+
+// SetSyntheticRoleProvider sets a callback invoked by GetRoles to add
+// computed roles to the roles of a user. A nil provider removes it. The
+// provider is called without the role manager locked, so it may call it.
+func (rm *RoleManager) SetSyntheticRoleProvider(provider SyntheticRoleProvider) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.syntheticRoleProvider = provider
+}
+
+// MetadataRule gives a synthetic role to the users having a given value in
+// their metadata, turning feature-flag-like attributes into roles.
+type MetadataRule struct {
+	// Path is the dot-separated path of the value, starting with
+	// "app_metadata" or "user_metadata", e.g. "app_metadata.beta".
+	Path string
+	// Value is compared to the string form of the metadata value, e.g. "true".
+	Value string
+	// Role is the synthetic role given to the matching users.
+	Role string
+}
+
+// ParseMetadataRule parses a rule written as "app_metadata.beta=true -> beta-tester".
+func ParseMetadataRule(s string) (MetadataRule, error) {
+	condition, role, ok := strings.Cut(s, "->")
+	if !ok {
+		return MetadataRule{}, fmt.Errorf("invalid metadata rule %q: missing \"->\"", s)
+	}
+	path, value, ok := strings.Cut(condition, "=")
+	if !ok {
+		return MetadataRule{}, fmt.Errorf("invalid metadata rule %q: missing \"=\"", s)
+	}
+
+	rule := MetadataRule{
+		Path:  strings.TrimSpace(path),
+		Value: strings.TrimSpace(value),
+		Role:  strings.TrimSpace(role),
+	}
+	return rule, rule.validate()
+}
+
+func (r MetadataRule) validate() error {
+	if !strings.HasPrefix(r.Path, "app_metadata.") && !strings.HasPrefix(r.Path, "user_metadata.") {
+		return fmt.Errorf("invalid metadata rule path %q: must start with \"app_metadata.\" or \"user_metadata.\"", r.Path)
+	}
+	if r.Role == "" {
+		return fmt.Errorf("invalid metadata rule for %q: missing role", r.Path)
+	}
+	return nil
+}
+
+// matches determines whether the metadata of user has the rule's value.
+func (r MetadataRule) matches(user *management.User) bool {
+	keys := strings.Split(r.Path, ".")
+
+	var value interface{}
+	switch keys[0] {
+	case "app_metadata":
+		if user.AppMetadata != nil {
+			value = *user.AppMetadata
+		}
+	case "user_metadata":
+		if user.UserMetadata != nil {
+			value = *user.UserMetadata
+		}
+	}
+
+	for _, key := range keys[1:] {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if value, ok = m[key]; !ok {
+			return false
+		}
+	}
+	return fmt.Sprint(value) == r.Value
+}
+
+// SetMetadataRules replaces the rules giving synthetic roles based on user
+// metadata. The rules are evaluated by GetRoles against the user profiles
+// loaded with the (ID, name) mapping.
+func (rm *RoleManager) SetMetadataRules(rules ...MetadataRule) error {
+	for _, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	rm.metadataRules = append([]MetadataRule{}, rules...)
+	return nil
+}
+
+// LoadMetadataRules reads the metadata rules, one per line in the format of
+// ParseMetadataRule. Empty lines and lines starting with "#" are ignored.
+func (rm *RoleManager) LoadMetadataRules(r io.Reader) error {
+	rules := []MetadataRule{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := ParseMetadataRule(line)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return rm.SetMetadataRules(rules...)
+}
@@ -0,0 +1,132 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/auth0/go-auth0"
+	"github.com/auth0/go-auth0/management"
+	"github.com/casbin/casbin/v2/util"
+)
+
+func newSyntheticTestRoleManager() *RoleManager {
+	return &RoleManager{
+		nameToIDMap: map[string]string{
+			"alice@test.com": "auth0|1",
+			"bob@test.com":   "auth0|2",
+			"carol":          "sms|3",
+		},
+		profiles: map[string]*management.User{
+			"auth0|1": {ID: auth0.String("auth0|1"), Email: auth0.String("alice@test.com"), EmailVerified: auth0.Bool(true)},
+			"auth0|2": {ID: auth0.String("auth0|2"), Email: auth0.String("bob@test.com"), Blocked: auth0.Bool(true)},
+			"sms|3":   {ID: auth0.String("sms|3"), PhoneNumber: auth0.String("+4700000000")},
+		},
+	}
+}
+
+func TestAccountStateRoles(t *testing.T) {
+	rm := newSyntheticTestRoleManager()
+
+	if res := rm.syntheticRoles("bob@test.com"); len(res) != 0 {
+		t.Errorf("bob@test.com: %s, supposed to be empty when disabled", res)
+	}
+
+	rm.EnableAccountStateRoles(true)
+	if res := rm.syntheticRoles("alice@test.com"); len(res) != 0 {
+		t.Errorf("alice@test.com: %s, supposed to be empty", res)
+	}
+	if res := rm.syntheticRoles("bob@test.com"); !util.ArrayEquals(res, []string{RoleBlocked, RoleEmailUnverified}) {
+		t.Errorf("bob@test.com: %s, supposed to be [%s %s]", res, RoleBlocked, RoleEmailUnverified)
+	}
+	// Users without an email have no email to verify.
+	if res := rm.syntheticRoles("carol"); len(res) != 0 {
+		t.Errorf("carol: %s, supposed to be empty", res)
+	}
+}
+
+func TestMetadataRules(t *testing.T) {
+	rm := newSyntheticTestRoleManager()
+	rm.profiles["auth0|1"].AppMetadata = &map[string]interface{}{
+		"beta": true,
+		"plan": map[string]interface{}{"tier": "gold"},
+	}
+
+	err := rm.LoadMetadataRules(strings.NewReader(`
+# feature flags
+app_metadata.beta=true -> beta-tester
+app_metadata.plan.tier = gold -> gold-customer
+user_metadata.beta=true -> beta-volunteer
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res := rm.syntheticRoles("alice@test.com"); !util.ArrayEquals(res, []string{"beta-tester", "gold-customer"}) {
+		t.Errorf("alice@test.com: %s, supposed to be [beta-tester gold-customer]", res)
+	}
+	if res := rm.syntheticRoles("bob@test.com"); len(res) != 0 {
+		t.Errorf("bob@test.com: %s, supposed to be empty", res)
+	}
+
+	if _, err := ParseMetadataRule("profile.beta=true -> beta-tester"); err == nil {
+		t.Error("paths outside of the metadata should be rejected")
+	}
+	if _, err := ParseMetadataRule("app_metadata.beta=true"); err == nil {
+		t.Error("rules without a role should be rejected")
+	}
+}
+
+func TestSyntheticRoleProvider(t *testing.T) {
+	rm := newSyntheticTestRoleManager()
+	rm.SetSyntheticRoleProvider(func(user ProfileView) []string {
+		if user.EmailVerified {
+			return []string{"verified"}
+		}
+		return nil
+	})
+
+	testPrintSyntheticRoles(t, rm, "alice@test.com", []string{"verified"})
+	testPrintSyntheticRoles(t, rm, "bob@test.com", []string{})
+	testPrintSyntheticRoles(t, rm, "carol@test.com", []string{})
+
+	// The provider can call the role manager.
+	rm.SetSyntheticRoleProvider(func(user ProfileView) []string {
+		locked := make(chan struct{})
+		go func() {
+			rm.mu.Lock()
+			defer rm.mu.Unlock()
+			close(locked)
+		}()
+		select {
+		case <-locked:
+		case <-time.After(time.Second):
+			t.Error("the provider should be called without the role manager locked")
+		}
+		return nil
+	})
+	testPrintSyntheticRoles(t, rm, "alice@test.com", []string{})
+}
+
+func testPrintSyntheticRoles(t *testing.T, rm *RoleManager, name string, res []string) {
+	t.Helper()
+	myRes := rm.syntheticRoles(name)
+
+	if !util.ArrayEquals(myRes, res) {
+		t.Errorf("%s: %s, supposed to be %s", name, myRes, res)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/auth0/go-auth0/management"
+)
+
+// Kinds of the issues reported by Validate.
+const (
+	// IssueMissingRole is an edge of the local role hierarchy referencing a
+	// role that no longer exists in Auth0.
+	IssueMissingRole = "missing_role"
+	// IssueUnreachableRole is a role that no user can obtain, neither
+	// directly nor through the role hierarchy.
+	IssueUnreachableRole = "unreachable_role"
+	// IssueExcessiveDepth is a role whose inheritance chain is longer than
+	// the maximum hierarchy level, so the roles at its end are ignored.
+	IssueExcessiveDepth = "excessive_depth"
+	// IssueNameCollision is a name shared by several users or roles, possibly
+	// differing only in case.
+	IssueNameCollision = "name_collision"
+)
+
+// defaultMaxHierarchyLevel is the default number of links of the local role
+// hierarchy followed, see SetMaxHierarchyLevel.
+const defaultMaxHierarchyLevel = 10
+
+// ValidationIssue is a single problem found by Validate.
+type ValidationIssue struct {
+	Kind    string   `json:"kind"`
+	Message string   `json:"message"`
+	Names   []string `json:"names"`
+}
+
+// ValidationReport is the result of Validate.
+type ValidationReport struct {
+	Issues []ValidationIssue `json:"issues"`
+}
+
+// OK returns true if no issue was found.
+func (r *ValidationReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *ValidationReport) add(kind string, names []string, format string, a ...interface{}) {
+	r.Issues = append(r.Issues, ValidationIssue{Kind: kind, Message: fmt.Sprintf(format, a...), Names: names})
+}
+
+// Validate checks the local role hierarchy against the Auth0 state. Issues
+// are reported in the returned report; an error is only returned if Auth0
+// could not be queried.
+func (rm *RoleManager) Validate() (*ValidationReport, error) {
+	return rm.ValidateCtx(context.Background())
+}
+
+// ValidateCtx is like Validate, with ctx bounding the Management API calls.
+func (rm *RoleManager) ValidateCtx(ctx context.Context) (*ValidationReport, error) {
+	report := &ValidationReport{Issues: []ValidationIssue{}}
+
+	// Take a snapshot of the local state, as the Auth0 queries are made
+	// without holding the lock.
+	rm.mu.RLock()
+	hierarchyRoles := sortedKeys(rm.hierarchyRoles())
+	missing := []string{}
+	for _, role := range hierarchyRoles {
+		if !rm.roles[role] {
+			missing = append(missing, role)
+		}
+	}
+	roles := sortedKeys(rm.roles)
+	inheriting := map[string][]string{}
+	for _, role := range roles {
+		for _, r := range append([]string{role}, rm.hierarchy.descendantsWithin(role, rm.hierarchyLevels())...) {
+			if rm.roles[r] {
+				inheriting[role] = append(inheriting[role], r)
+			}
+		}
+	}
+	depths := map[string]int{}
+	for _, role := range hierarchyRoles {
+		rm.hierarchy.depth(role, depths)
+	}
+	collisions := rm.nameCollisions()
+	maxLevel := rm.hierarchyLevels()
+	rm.mu.RUnlock()
+
+	for _, role := range missing {
+		report.add(IssueMissingRole, []string{role},
+			"role %s is used in the role hierarchy but does not exist in Auth0", role)
+	}
+
+	for _, role := range roles {
+		reachable, err := rm.isReachable(ctx, inheriting[role])
+		if err != nil {
+			return nil, err
+		}
+		if !reachable {
+			report.add(IssueUnreachableRole, []string{role},
+				"role %s has no users, neither directly nor through the role hierarchy", role)
+		}
+	}
+
+	for _, role := range hierarchyRoles {
+		if depth := depths[role]; depth > maxLevel {
+			report.add(IssueExcessiveDepth, []string{role},
+				"role %s has an inheritance chain of %d links, more than %d", role, depth, maxLevel)
+		}
+	}
+
+	for _, names := range collisions {
+		report.add(IssueNameCollision, names,
+			"names %s collide", strings.Join(names, ", "))
+	}
+
+	return report, nil
+}
+
+// hierarchyRoles returns the set of roles used by the local role hierarchy.
+// rm.mu must be held.
+func (rm *RoleManager) hierarchyRoles() map[string]bool {
+	res := map[string]bool{}
+	for role, parents := range rm.hierarchy.parents {
+		res[role] = true
+		for parent := range parents {
+			res[parent] = true
+		}
+	}
+	return res
+}
+
+// isReachable determines whether any of roles, a role and the Auth0 roles
+// inheriting it, has at least one Auth0 user.
+func (rm *RoleManager) isReachable(ctx context.Context, roles []string) (bool, error) {
+	for _, r := range roles {
+		roleID, err := rm.roleID(ctx, r)
+		if err != nil {
+			return false, err
+		}
+		// A single user is enough, whatever the size of the role.
+		var users *management.UserList
+		err = rm.call(ctx, func() error {
+			var err error
+			users, err = rm.api.RoleUsers(ctx, roleID, ListOptions{Take: 1})
+			return err
+		})
+		if err != nil {
+			return false, err
+		}
+		if len(users.Users) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// nameCollisions groups the user and role names that are equal ignoring
+// case and belong to different Auth0 IDs. rm.mu must be held.
+func (rm *RoleManager) nameCollisions() [][]string {
+	ids := map[string][]string{}
+	for id, name := range rm.idToNameMap {
+		key := strings.ToLower(name)
+		ids[key] = append(ids[key], id)
+	}
+
+	res := [][]string{}
+	for _, key := range sortedStringKeys(ids) {
+		if len(ids[key]) < 2 {
+			continue
+		}
+		names := []string{}
+		for _, id := range ids[key] {
+			names = append(names, fmt.Sprintf("%s (%s)", rm.idToNameMap[id], id))
+		}
+		sort.Strings(names)
+		res = append(res, names)
+	}
+	return res
+}
+
+// depth returns the number of links of the longest inheritance chain
+// starting at role. depths memoizes the results across calls.
+func (h *roleHierarchy) depth(role string, depths map[string]int) int {
+	if d, ok := depths[role]; ok {
+		return d
+	}
+	// Guard against cycles: a role being visited counts as a leaf.
+	depths[role] = 0
+	max := 0
+	for parent := range h.parents[role] {
+		if d := h.depth(parent, depths) + 1; d > max {
+			max = d
+		}
+	}
+	depths[role] = max
+	return max
+}
+
+func sortedStringKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
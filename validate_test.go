@@ -0,0 +1,107 @@
+// Copyright 2018 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth0rolemanager
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/olvesh/auth0-role-manager/v2/auth0test"
+)
+
+func TestHierarchyDepth(t *testing.T) {
+	h := newRoleHierarchy()
+	for i := 0; i < defaultMaxHierarchyLevel+1; i++ {
+		h.addEdge(fmt.Sprintf("role%d", i), fmt.Sprintf("role%d", i+1))
+	}
+
+	depths := map[string]int{}
+	if d := h.depth("role0", depths); d != defaultMaxHierarchyLevel+1 {
+		t.Errorf("depth of role0: %d, supposed to be %d", d, defaultMaxHierarchyLevel+1)
+	}
+	if d := h.depth("role5", depths); d != defaultMaxHierarchyLevel-4 {
+		t.Errorf("depth of role5: %d, supposed to be %d", d, defaultMaxHierarchyLevel-4)
+	}
+}
+
+func TestNameCollisions(t *testing.T) {
+	rm := &RoleManager{
+		idToNameMap: map[string]string{
+			"auth0|1":  "alice@test.com",
+			"rol_1":    "Admin",
+			"rol_2":    "admin",
+			"rol_3":    "Group1",
+			"auth0|2":  "bob@test.com",
+			"google|3": "bob@test.com",
+		},
+	}
+
+	res := rm.nameCollisions()
+	if len(res) != 2 {
+		t.Fatalf("collisions: %v, supposed to be 2 groups", res)
+	}
+	if res[0][0] != "Admin (rol_1)" || res[0][1] != "admin (rol_2)" {
+		t.Errorf("collision: %v, supposed to be [Admin (rol_1) admin (rol_2)]", res[0])
+	}
+	if res[1][0] != "bob@test.com (auth0|2)" || res[1][1] != "bob@test.com (google|3)" {
+		t.Errorf("collision: %v, supposed to be [bob@test.com (auth0|2) bob@test.com (google|3)]", res[1])
+	}
+}
+
+func TestReachableRoles(t *testing.T) {
+	fake := auth0test.New()
+	addRole(fake, "rol_admin", "admin")
+	addRole(fake, "rol_editor", "editor")
+	addRole(fake, "rol_viewer", "viewer")
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprintf("auth0|%d", i)
+		addUser(fake, id, fmt.Sprintf("user%d@example.com", i))
+		assignRoles(t, fake, id, "rol_editor")
+	}
+	rm := newFakeRoleManager(t, fake, WithPageSize(5))
+	if err := rm.AddLink("editor", "viewer"); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := rm.Validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unreachable := []string{}
+	for _, issue := range report.Issues {
+		if issue.Kind == IssueUnreachableRole {
+			unreachable = append(unreachable, issue.Names...)
+		}
+	}
+	if len(unreachable) != 1 || unreachable[0] != "admin" {
+		t.Errorf("unreachable roles: %v, supposed to be [admin]", unreachable)
+	}
+
+	// A single user of a role is fetched to know it has some: one call for
+	// admin and editor, two for viewer and editor inheriting it.
+	calls := 0
+	for _, c := range fake.Calls() {
+		if c.Method != "RoleUsers" {
+			continue
+		}
+		calls++
+		if c.Options.Take != 1 {
+			t.Errorf("call: %+v, supposed to fetch a single user", c)
+		}
+	}
+	if calls != 4 {
+		t.Errorf("role user listings: %d, supposed to be 4", calls)
+	}
+}